@@ -0,0 +1,323 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// 签约产品码须成对使用，由调用方按签约的产品选择
+const (
+	AgreementProductCodeCycle               = "CYCLE_PAY_AUTH"        // 周期扣款销售产品码
+	AgreementPersonalProductCodeCycle       = "CYCLE_PAY_AUTH_P"      // 周期扣款个人签约产品码
+	AgreementProductCodeWithholding         = "GENERAL_WITHHOLDING"   // 商家扣款销售产品码
+	AgreementPersonalProductCodeWithholding = "GENERAL_WITHHOLDING_P" // 商家扣款个人签约产品码
+)
+
+// agreementProductPairs 销售产品码 => 个人签约产品码
+var agreementProductPairs = map[string]string{
+	AgreementProductCodeCycle:       AgreementPersonalProductCodeCycle,
+	AgreementProductCodeWithholding: AgreementPersonalProductCodeWithholding,
+}
+
+// AgreementStatus 协议状态
+type AgreementStatus string
+
+const (
+	AgreementStatusTemp   AgreementStatus = "TEMP"   // 暂存，协议未生效
+	AgreementStatusNormal AgreementStatus = "NORMAL" // 正常
+	AgreementStatusStop   AgreementStatus = "STOP"   // 暂停
+	AgreementStatusUnsign AgreementStatus = "UNSIGN" // 已解约，仅出现在解约通知中
+)
+
+const (
+	AgreementNotifyTypeSign   = "dut_user_sign"   // 签约通知
+	AgreementNotifyTypeUnsign = "dut_user_unsign" // 解约通知
+)
+
+// AgreementAccessParams 签约接入方式
+type AgreementAccessParams struct {
+	Channel string `json:"channel" validate:"required,max=64"` // ALIPAYAPP 钱包H5页面签约，QRCODE 扫码签约，QRCODEORSMS 扫码或短信签约
+}
+
+// AgreementPeriodRuleParams 周期管控规则参数
+type AgreementPeriodRuleParams struct {
+	PeriodType    string `json:"period_type" validate:"required,max=20"` // DAY 按天，MONTH 按月
+	Period        int    `json:"period" validate:"required"`
+	ExecuteTime   string `json:"execute_time" validate:"required,max=10"` // 首次扣款日期，yyyy-MM-dd
+	SingleAmount  string `json:"single_amount" validate:"required,amount"`
+	TotalAmount   string `json:"total_amount,omitempty" validate:"amount"`
+	TotalPayments int    `json:"total_payments,omitempty"`
+}
+
+// AgreementPageSignRequest 支付宝个人协议页面签约请求参数
+type AgreementPageSignRequest struct {
+	PersonalProductCode string                     `json:"personal_product_code" validate:"required,max=64"`
+	ProductCode         string                     `json:"product_code" validate:"required,max=64"`
+	SignScene           string                     `json:"sign_scene" validate:"required,max=64"`
+	ExternalAgreementNo string                     `json:"external_agreement_no,omitempty" validate:"max=32"`
+	ExternalLogonId     string                     `json:"external_logon_id,omitempty" validate:"max=100"`
+	AccessParams        *AgreementAccessParams     `json:"access_params" validate:"required"`
+	PeriodRuleParams    *AgreementPeriodRuleParams `json:"period_rule_params,omitempty"`
+	SignValidityPeriod  string                     `json:"sign_validity_period,omitempty" validate:"max=8"`
+	ThirdPartyType      string                     `json:"third_party_type,omitempty" validate:"max=32"`
+	MerchantProcessUrl  string                     `json:"merchant_process_url,omitempty" validate:"max=1024"`
+}
+
+// Validate 校验支付宝个人协议页面签约请求参数
+func (req *AgreementPageSignRequest) Validate() error {
+	return validateFields(req)
+}
+
+// AgreementQueryRequest 支付宝个人代扣协议查询请求参数
+// agreement_no 与 alipay_user_id/alipay_logon_id、sign_scene、external_agreement_no 组合二选一
+type AgreementQueryRequest struct {
+	AgreementNo         string `json:"agreement_no,omitempty" validate:"max=64"`
+	PersonalProductCode string `json:"personal_product_code,omitempty" validate:"max=64"`
+	AlipayUserId        string `json:"alipay_user_id,omitempty" validate:"max=16"`
+	AlipayOpenId        string `json:"alipay_open_id,omitempty" validate:"max=128"`
+	AlipayLogonId       string `json:"alipay_logon_id,omitempty" validate:"max=100"`
+	SignScene           string `json:"sign_scene,omitempty" validate:"max=64"`
+	ExternalAgreementNo string `json:"external_agreement_no,omitempty" validate:"max=32"`
+	ThirdPartyType      string `json:"third_party_type,omitempty" validate:"max=32"`
+}
+
+// Validate 校验支付宝个人代扣协议查询请求参数
+func (req *AgreementQueryRequest) Validate() error {
+	if err := validateFields(req); err != nil {
+		return err
+	}
+	if req.AgreementNo == "" && req.ExternalAgreementNo == "" &&
+		req.AlipayUserId == "" && req.AlipayOpenId == "" && req.AlipayLogonId == "" {
+		return errors.New("agreement_no、external_agreement_no 及用户标识不能同时为空")
+	}
+	return nil
+}
+
+// AgreementUnsignRequest 支付宝个人代扣协议解约请求参数
+type AgreementUnsignRequest struct {
+	AgreementNo         string `json:"agreement_no,omitempty" validate:"max=64"`
+	PersonalProductCode string `json:"personal_product_code,omitempty" validate:"max=64"`
+	AlipayUserId        string `json:"alipay_user_id,omitempty" validate:"max=16"`
+	AlipayOpenId        string `json:"alipay_open_id,omitempty" validate:"max=128"`
+	AlipayLogonId       string `json:"alipay_logon_id,omitempty" validate:"max=100"`
+	SignScene           string `json:"sign_scene,omitempty" validate:"max=64"`
+	ExternalAgreementNo string `json:"external_agreement_no,omitempty" validate:"max=32"`
+	ThirdPartyType      string `json:"third_party_type,omitempty" validate:"max=32"`
+	ExtendParams        string `json:"extend_params,omitempty" validate:"max=2048"`
+	OperateType         string `json:"operate_type,omitempty" validate:"max=16"` // confirm 解约确认，invalid 解约作废
+}
+
+// Validate 校验支付宝个人代扣协议解约请求参数
+func (req *AgreementUnsignRequest) Validate() error {
+	if err := validateFields(req); err != nil {
+		return err
+	}
+	if req.AgreementNo == "" && req.ExternalAgreementNo == "" &&
+		req.AlipayUserId == "" && req.AlipayOpenId == "" && req.AlipayLogonId == "" {
+		return errors.New("agreement_no、external_agreement_no 及用户标识不能同时为空")
+	}
+	return nil
+}
+
+// AgreementParams 代扣协议信息
+type AgreementParams struct {
+	AgreementNo      string `json:"agreement_no" validate:"required,max=64"`
+	AuthConfirmNo    string `json:"auth_confirm_no,omitempty" validate:"max=64"`
+	ApplyToken       string `json:"apply_token,omitempty" validate:"max=64"`
+	DeductPermission string `json:"deduct_permission,omitempty" validate:"max=64"`
+}
+
+// AgreementTradePayRequest 协议代扣请求参数
+type AgreementTradePayRequest struct {
+	OutTradeNo      string           `json:"out_trade_no" validate:"required,max=64"`
+	TotalAmount     string           `json:"total_amount" validate:"required,amount"`
+	Subject         string           `json:"subject" validate:"required,max=256"`
+	AgreementParams *AgreementParams `json:"agreement_params" validate:"required"`
+	ProductCode     string           `json:"product_code" validate:"required,max=32"` // 与签约时的销售产品码一致
+	SellerId        string           `json:"seller_id,omitempty" validate:"max=28"`
+	Body            string           `json:"body,omitempty" validate:"max=128"`
+	GoodsDetail     []GoodsDetail    `json:"goods_detail,omitempty"`
+	ExtendParams    *ExtendParams    `json:"extend_params,omitempty"`
+	StoreId         string           `json:"store_id,omitempty" validate:"max=32"`
+	TerminalId      string           `json:"terminal_id,omitempty" validate:"max=32"`
+	TimeoutExpress  string           `json:"timeout_express,omitempty" validate:"max=6"`
+	SettleInfo      *SettleInfo      `json:"settle_info,omitempty"`
+}
+
+// Validate 校验协议代扣请求参数
+func (req *AgreementTradePayRequest) Validate() error {
+	return validateFields(req)
+}
+
+// agreementQueryResponse 支付宝个人代扣协议查询响应参数
+type agreementQueryResponse struct {
+	PayResponse
+	Response AgreementQueryResponseData `json:"alipay_user_agreement_query_response"`
+}
+
+// AgreementQueryResponseData 支付宝个人代扣协议查询响应参数数据
+type AgreementQueryResponseData struct {
+	PayResponseData
+	AgreementNo         string          `json:"agreement_no"`
+	ExternalAgreementNo string          `json:"external_agreement_no"`
+	Status              AgreementStatus `json:"status"`
+	PersonalProductCode string          `json:"personal_product_code"`
+	SignScene           string          `json:"sign_scene"`
+	SignTime            string          `json:"sign_time"`
+	ValidTime           string          `json:"valid_time"`
+	InvalidTime         string          `json:"invalid_time"`
+	AlipayLogonId       string          `json:"alipay_logon_id"`
+	PrincipalId         string          `json:"principal_id"`
+	PrincipalOpenId     string          `json:"principal_open_id"`
+	PricipalType        string          `json:"pricipal_type"` // 支付宝接口字段原文如此
+	ExternalLogonId     string          `json:"external_logon_id"`
+	ThirdPartyType      string          `json:"third_party_type"`
+	ZmOpenId            string          `json:"zm_open_id"`
+	CreditAuthMode      string          `json:"credit_auth_mode"`
+	SingleQuota         string          `json:"single_quota"`
+	LastDeductTime      string          `json:"last_deduct_time"`
+	NextDeductTime      string          `json:"next_deduct_time"`
+}
+
+// AgreementNotification 协议签约、解约异步通知参数
+type AgreementNotification struct {
+	NotifyTime          string          `json:"notify_time"`
+	NotifyType          string          `json:"notify_type"` // dut_user_sign 签约，dut_user_unsign 解约
+	NotifyId            string          `json:"notify_id"`
+	AppId               string          `json:"app_id"`
+	AuthAppId           string          `json:"auth_app_id"`
+	Charset             string          `json:"charset"`
+	Version             string          `json:"version"`
+	AgreementNo         string          `json:"agreement_no"`
+	ExternalAgreementNo string          `json:"external_agreement_no"`
+	Status              AgreementStatus `json:"status"`
+	PersonalProductCode string          `json:"personal_product_code"`
+	SignScene           string          `json:"sign_scene"`
+	SignTime            string          `json:"sign_time"`
+	UnsignTime          string          `json:"unsign_time"`
+	ValidTime           string          `json:"valid_time"`
+	InvalidTime         string          `json:"invalid_time"`
+	AlipayUserId        string          `json:"alipay_user_id"`
+	AlipayOpenId        string          `json:"alipay_open_id"`
+	AlipayLogonId       string          `json:"alipay_logon_id"`
+	ExternalLogonId     string          `json:"external_logon_id"`
+	ZmOpenId            string          `json:"zm_open_id"`
+	CreditAuthMode      string          `json:"credit_auth_mode"`
+	SingleQuota         string          `json:"single_quota"`
+	LoginToken          string          `json:"login_token"`
+}
+
+// AgreementPageSign 支付宝个人协议页面签约，生成跳转至支付宝签约页面的地址
+// 签约结果通过异步通知获取，异步通知地址使用 WithNotifyUrl 设置
+// @params bizContent interface{} 业务数据，*AgreementPageSignRequest 或 map[string]interface{}
+func (pay *AliPay) AgreementPageSign(bizContent interface{}) (signUrl string, err error) {
+	m, err := bizMap(bizContent)
+	if err != nil {
+		return "", err
+	}
+	if err = checkAgreementProduct(m); err != nil {
+		return "", err
+	}
+
+	return pay.pageUrl("alipay.user.agreement.page.sign", m)
+}
+
+// checkAgreementProduct 校验签约产品码，销售产品码与个人签约产品码须属于同一产品
+func checkAgreementProduct(bizContent map[string]interface{}) error {
+	productCode, _ := bizContent["product_code"].(string)
+	personalProductCode, _ := bizContent["personal_product_code"].(string)
+	if productCode == "" || personalProductCode == "" {
+		return errors.New("product_code 和 personal_product_code 不能为空")
+	}
+
+	if pair, ok := agreementProductPairs[productCode]; ok && pair != personalProductCode {
+		return errors.Errorf("product_code %s 须与 personal_product_code %s 配合使用", productCode, pair)
+	}
+	if productCode == AgreementProductCodeCycle {
+		if _, ok := bizContent["period_rule_params"]; !ok {
+			return errors.New("周期扣款签约 period_rule_params 不能为空")
+		}
+	}
+
+	return nil
+}
+
+// AgreementQuery 支付宝个人代扣协议查询
+// @params bizContent interface{} 业务数据，*AgreementQueryRequest 或 map[string]interface{}
+func (pay *AliPay) AgreementQuery(ctx context.Context, bizContent interface{}) (*AgreementQueryResponseData, error) {
+	m, err := bizMap(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	biz, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.user.agreement.query", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result agreementQueryResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
+
+// AgreementUnsign 支付宝个人代扣协议解约
+// @params bizContent interface{} 业务数据，*AgreementUnsignRequest 或 map[string]interface{}
+func (pay *AliPay) AgreementUnsign(ctx context.Context, bizContent interface{}) error {
+	m, err := bizMap(bizContent)
+	if err != nil {
+		return err
+	}
+
+	return pay.Execute(ctx, "alipay.user.agreement.unsign", m, nil)
+}
+
+// AgreementPay 协议代扣，使用用户签约的代扣协议发起扣款
+// 若请求失败或支付接口返回处理结果未知的错误，会以商户订单号查询交易结果，已支付成功时视为本次支付成功
+// @params bizContent interface{} 业务数据，*AgreementTradePayRequest 或 map[string]interface{}
+func (pay *AliPay) AgreementPay(ctx context.Context, bizContent interface{}) (*TradePayResponseData, error) {
+	m, err := bizMap(bizContent)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := m["agreement_params"]; !ok {
+		return nil, errors.New("agreement_params 不能为空")
+	}
+	if productCode, _ := m["product_code"].(string); productCode == "" {
+		return nil, errors.New("product_code 不能为空，须与签约时的销售产品码一致")
+	}
+
+	return pay.tradePay(ctx, m)
+}
+
+// AgreementHandler 处理协议签约、解约异步通知
+// 验证签名及应用ID后调用业务回调，并向支付宝响应 success 或 failure
+// @params writer http.ResponseWriter 通知响应
+// @params request *http.Request 通知请求
+// @params bizCallback func(notification *AgreementNotification) error 业务回调
+func (notify *notify) AgreementHandler(
+	writer http.ResponseWriter,
+	request *http.Request,
+	bizCallback func(notification *AgreementNotification) error,
+) error {
+	notification := new(AgreementNotification)
+	return notify.handle(writer, request, notification, func(url.Values) error {
+		return errors.Wrap(bizCallback(notification), "协议业务处理失败")
+	})
+}
@@ -1,39 +1,71 @@
 package alipay
 
 import (
+	"context"
+	cryptoRsa "crypto/rsa"
+	"net/http"
 	"net/url"
 
 	"github.com/dysodeng/payment/support/crypto/rsa"
+	"github.com/pkg/errors"
 )
 
 // AliPay 支付宝
 type AliPay struct {
-	config *config
+	config          *config
+	cert            *certStore            // 公钥证书模式下的证书信息，非证书模式时为nil
+	privateKey      *cryptoRsa.PrivateKey // 应用私钥
+	alipayPublicKey *cryptoRsa.PublicKey  // 支付宝公钥，公钥证书模式时为nil
 }
 
 // New create alipay
 // @param appId string 应用ID
-// @param alipayPublicKey string 支付宝公钥
-// @param privateKey string 应用私钥
-func New(appId, alipayPublicKey, privateKey string, opts ...Option) *AliPay {
+// @param alipayPublicKey string 支付宝公钥，PEM格式或base64编码，公钥证书模式下可为空
+// @param privateKey string 应用私钥，PEM格式（PKCS#1 或 PKCS#8）或base64编码
+func New(appId, alipayPublicKey, privateKey string, opts ...Option) (*AliPay, error) {
 	c := &config{
 		isDev:           false,
 		appId:           appId,
 		alipayPublicKey: alipayPublicKey,
 		privateKey:      privateKey,
+		charset:         CharsetUtf8,
+		httpClient:      &http.Client{Timeout: defaultHttpTimeout},
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
-	return &AliPay{
+	pay := &AliPay{
 		config: c,
 	}
+	if err := pay.checkCharset(); err != nil {
+		return nil, err
+	}
+	if err := pay.checkEncryptKey(); err != nil {
+		return nil, err
+	}
+	if err := pay.loadCert(); err != nil {
+		return nil, err
+	}
+	if err := pay.loadKeys(); err != nil {
+		return nil, err
+	}
+
+	return pay, nil
 }
 
 // CheckCallbackSign 检查支付回调参数签名
-func (pay *AliPay) CheckCallbackSign(params url.Values) (bool, error) {
+// 公钥证书模式下仅使用已知的支付宝公钥证书，证书轮换后的新证书在同步接口调用时自动下载
+func (pay *AliPay) CheckCallbackSign(ctx context.Context, params url.Values) (bool, error) {
+	sign := params.Get("sign")
+	if sign == "" {
+		return false, errors.New("回调参数缺少签名")
+	}
+	if signType := params.Get("sign_type"); signType != "" && signType != "RSA2" {
+		return false, errors.Errorf("不支持的签名类型: %s", signType)
+	}
+
 	m := make(map[string]string)
 	for key, value := range params {
 		if key == "sign" || key == "sign_type" {
@@ -42,7 +74,28 @@ func (pay *AliPay) CheckCallbackSign(params url.Values) (bool, error) {
 		m[key] = value[0]
 	}
 
-	sign := params["sign"][0]
+	publicKey, err := pay.alipayPublicKeyFor(ctx, params.Get("alipay_cert_sn"), false)
+	if err != nil {
+		return false, err
+	}
+
+	// 待验签内容须为通知原始编码，GBK通知参数已被转换为UTF-8时转换回GBK后重新验签
+	ok, err := rsa.CheckWithKey(pay.signString(m), sign, publicKey)
+	if ok || !isGbk(params.Get("charset")) {
+		return ok, err
+	}
+
+	encoded, encodeErr := encodeParams(m, params.Get("charset"))
+	if encodeErr != nil {
+		return false, encodeErr
+	}
+
+	return rsa.CheckWithKey(pay.signString(encoded), sign, publicKey)
+}
 
-	return rsa.Check(pay.signString(m), sign, pay.alipayPublicKey())
+// Notify 异步通知
+func (pay *AliPay) Notify() *notify {
+	return &notify{
+		pay: pay,
+	}
 }
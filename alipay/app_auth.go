@@ -0,0 +1,222 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	prodAppAuthUrl = "https://openauth.alipay.com/oauth2/appToAppAuth.htm"    // 线上环境第三方应用授权地址
+	devAppAuthUrl  = "https://openauth.alipaydev.com/oauth2/appToAppAuth.htm" // 沙箱环境第三方应用授权地址
+
+	appAuthTokenRefreshBefore = 24 * time.Hour // 令牌到期前提前刷新的时间
+)
+
+// AppAuthToken 商户授权令牌
+type AppAuthToken struct {
+	AppAuthToken    string      `json:"app_auth_token"`
+	AppRefreshToken string      `json:"app_refresh_token"`
+	AuthAppId       string      `json:"auth_app_id"`
+	UserId          string      `json:"user_id"`
+	ExpiresIn       json.Number `json:"expires_in"`    // 令牌有效期，单位秒
+	ReExpiresIn     json.Number `json:"re_expires_in"` // 刷新令牌有效期，单位秒
+	ExpiresAt       time.Time   `json:"expires_at"`    // 令牌过期时间，换取令牌时计算
+}
+
+// expiring 令牌是否即将过期
+func (token *AppAuthToken) expiring() bool {
+	return !token.ExpiresAt.IsZero() && time.Until(token.ExpiresAt) < appAuthTokenRefreshBefore
+}
+
+// AppAuthTokenStore 商户授权令牌存储，用于按授权商户应用ID持久化令牌
+type AppAuthTokenStore interface {
+	// Get 获取授权令牌，不存在时返回 nil, nil
+	Get(ctx context.Context, authAppId string) (*AppAuthToken, error)
+	// Set 保存授权令牌
+	Set(ctx context.Context, authAppId string, token *AppAuthToken) error
+}
+
+// appAuthTokenResponse 换取应用授权令牌响应参数
+type appAuthTokenResponse struct {
+	PayResponse
+	Response appAuthTokenResponseData `json:"alipay_open_auth_token_app_response"`
+}
+
+// appAuthTokenResponseData 换取应用授权令牌响应参数数据
+type appAuthTokenResponseData struct {
+	PayResponseData
+	AppAuthToken
+	Tokens []AppAuthToken `json:"tokens"`
+}
+
+// appAuthTokenQueryResponse 查询授权信息响应参数
+type appAuthTokenQueryResponse struct {
+	PayResponse
+	Response AppAuthTokenQueryResponseData `json:"alipay_open_auth_token_app_query_response"`
+}
+
+// AppAuthTokenQueryResponseData 查询授权信息响应参数数据
+type AppAuthTokenQueryResponseData struct {
+	PayResponseData
+	UserId      string      `json:"user_id"`
+	AuthAppId   string      `json:"auth_app_id"`
+	ExpiresIn   json.Number `json:"expires_in"`
+	AuthMethods []string    `json:"auth_methods"`
+	AuthStart   string      `json:"auth_start"`
+	AuthEnd     string      `json:"auth_end"`
+	Status      string      `json:"status"` // valid 有效，invalid 无效
+}
+
+// AppAuthUrl 生成第三方应用授权地址，商户授权后携带 app_auth_code 跳转至 redirectUri
+// @params redirectUri string 授权回调地址
+// @params state string 自定义参数，授权回调时原样返回
+func (pay *AliPay) AppAuthUrl(redirectUri, state string) string {
+	values := url.Values{}
+	values.Set("app_id", pay.config.appId)
+	values.Set("redirect_uri", redirectUri)
+	if state != "" {
+		values.Set("state", state)
+	}
+
+	authUrl := prodAppAuthUrl
+	if pay.config.isDev {
+		authUrl = devAppAuthUrl
+	}
+
+	return authUrl + "?" + values.Encode()
+}
+
+// ExchangeAppAuthToken 使用 app_auth_code 换取应用授权令牌
+// @params code string 授权回调中的 app_auth_code
+func (pay *AliPay) ExchangeAppAuthToken(ctx context.Context, code string) (*AppAuthToken, error) {
+	if code == "" {
+		return nil, errors.New("code 不能为空")
+	}
+	return pay.appAuthToken(ctx, map[string]interface{}{
+		"grant_type": "authorization_code",
+		"code":       code,
+	})
+}
+
+// RefreshAppAuthToken 使用 app_refresh_token 刷新应用授权令牌
+// @params refreshToken string 刷新令牌
+func (pay *AliPay) RefreshAppAuthToken(ctx context.Context, refreshToken string) (*AppAuthToken, error) {
+	if refreshToken == "" {
+		return nil, errors.New("refresh_token 不能为空")
+	}
+	return pay.appAuthToken(ctx, map[string]interface{}{
+		"grant_type":    "refresh_token",
+		"refresh_token": refreshToken,
+	})
+}
+
+// appAuthToken 换取或刷新应用授权令牌
+func (pay *AliPay) appAuthToken(ctx context.Context, bizContent map[string]interface{}) (*AppAuthToken, error) {
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.open.auth.token.app", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result appAuthTokenResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	token := result.Response.AppAuthToken
+	if token.AppAuthToken == "" && len(result.Response.Tokens) > 0 {
+		token = result.Response.Tokens[0]
+	}
+	if token.AppAuthToken == "" {
+		return nil, errors.New("响应中未包含应用授权令牌")
+	}
+	if expiresIn, err := token.ExpiresIn.Int64(); err == nil && expiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+
+	return &token, nil
+}
+
+// QueryAppAuthToken 查询某个应用授权令牌的授权信息
+// @params appAuthToken string 应用授权令牌
+func (pay *AliPay) QueryAppAuthToken(ctx context.Context, appAuthToken string) (*AppAuthTokenQueryResponseData, error) {
+	if appAuthToken == "" {
+		return nil, errors.New("app_auth_token 不能为空")
+	}
+
+	biz, err := json.Marshal(map[string]interface{}{
+		"app_auth_token": appAuthToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.open.auth.token.app.query", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result appAuthTokenQueryResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
+
+// WithAppAuth 返回代指定商户调用接口的客户端，与当前客户端共享证书及HTTP配置
+// @params appAuthToken string 商户授权令牌
+func (pay *AliPay) WithAppAuth(appAuthToken string) *AliPay {
+	c := *pay.config
+	c.appAuthToken = appAuthToken
+	return &AliPay{
+		config:          &c,
+		cert:            pay.cert,
+		privateKey:      pay.privateKey,
+		alipayPublicKey: pay.alipayPublicKey,
+	}
+}
+
+// WithStoredAppAuth 从令牌存储中获取商户授权令牌，返回代该商户调用接口的客户端
+// 令牌即将过期时自动刷新并写回存储
+// @params store AppAuthTokenStore 令牌存储
+// @params authAppId string 授权商户的应用ID
+func (pay *AliPay) WithStoredAppAuth(ctx context.Context, store AppAuthTokenStore, authAppId string) (*AliPay, error) {
+	token, err := store.Get(ctx, authAppId)
+	if err != nil {
+		return nil, errors.Wrap(err, "获取商户授权令牌失败")
+	}
+	if token == nil {
+		return nil, errors.Errorf("商户 %s 未授权", authAppId)
+	}
+
+	if token.expiring() {
+		token, err = pay.RefreshAppAuthToken(ctx, token.AppRefreshToken)
+		if err != nil {
+			return nil, errors.Wrap(err, "刷新商户授权令牌失败")
+		}
+		if err = store.Set(ctx, authAppId, token); err != nil {
+			return nil, errors.Wrap(err, "保存商户授权令牌失败")
+		}
+	}
+
+	return pay.WithAppAuth(token.AppAuthToken), nil
+}
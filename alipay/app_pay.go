@@ -0,0 +1,65 @@
+package alipay
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+// appPayProductCode App支付产品码
+const appPayProductCode = "QUICK_MSECURITY_PAY"
+
+// TradeAppPayRequest App支付请求参数
+type TradeAppPayRequest struct {
+	OutTradeNo      string        `json:"out_trade_no" validate:"required,max=64"`
+	TotalAmount     string        `json:"total_amount" validate:"required,amount"`
+	Subject         string        `json:"subject" validate:"required,max=256"`
+	ProductCode     string        `json:"product_code,omitempty" validate:"max=64"`
+	Body            string        `json:"body,omitempty" validate:"max=128"`
+	GoodsDetail     []GoodsDetail `json:"goods_detail,omitempty"`
+	TimeExpire      string        `json:"time_expire,omitempty" validate:"max=32"`
+	TimeoutExpress  string        `json:"timeout_express,omitempty" validate:"max=6"`
+	ExtendParams    *ExtendParams `json:"extend_params,omitempty"`
+	PassbackParams  string        `json:"passback_params,omitempty" validate:"max=512"`
+	MerchantOrderNo string        `json:"merchant_order_no,omitempty" validate:"max=32"`
+	SettleInfo      *SettleInfo   `json:"settle_info,omitempty"`
+}
+
+// Validate 校验App支付请求参数
+func (req *TradeAppPayRequest) Validate() error {
+	return validateFields(req)
+}
+
+// AppPay App支付，生成客户端调起支付宝SDK所需的订单信息字符串
+// 该接口仅在本地完成签名，不请求支付宝网关
+// @params bizContent interface{} 业务数据，*TradeAppPayRequest 或 map[string]interface{}
+func (pay *AliPay) AppPay(bizContent interface{}) (orderStr string, err error) {
+	m, err := bizMap(bizContent)
+	if err != nil {
+		return "", err
+	}
+
+	return pay.sdkOrderStr("alipay.trade.app.pay", withBizDefault(m, "product_code", appPayProductCode))
+}
+
+// sdkOrderStr 生成客户端调起支付宝SDK所需的订单信息字符串
+// @params method string 接口方法
+// @params bizContent map[string]interface{} 业务数据
+func (pay *AliPay) sdkOrderStr(method string, bizContent map[string]interface{}) (string, error) {
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return "", err
+	}
+
+	params, err := pay.signedParams(method, string(biz), nil)
+	if err != nil {
+		return "", err
+	}
+
+	// 签名后再对参数值进行URL编码
+	values := url.Values{}
+	for key, value := range params {
+		values.Add(key, value)
+	}
+
+	return values.Encode(), nil
+}
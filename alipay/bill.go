@@ -0,0 +1,427 @@
+package alipay
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dysodeng/payment/support"
+	"github.com/pkg/errors"
+)
+
+// BillType 账单类型
+type BillType string
+
+const (
+	BillTypeTrade        BillType = "trade"        // 商户基于支付宝交易收单的业务账单
+	BillTypeSignCustomer BillType = "signcustomer" // 基于商户支付宝余额收入及支出等资金变动的账务账单
+)
+
+var (
+	billSummaryPattern = regexp.MustCompile(`^#(\S+?)合计：(\d+)笔`)
+	billAmountPattern  = regexp.MustCompile(`(-?\d+(?:\.\d+)?)元`)
+)
+
+// billDownloadUrlResponse 查询对账单下载地址响应参数
+type billDownloadUrlResponse struct {
+	PayResponse
+	Response billDownloadUrlResponseData `json:"alipay_data_dataservice_bill_downloadurl_query_response"`
+}
+
+// billDownloadUrlResponseData 查询对账单下载地址响应参数数据
+type billDownloadUrlResponseData struct {
+	PayResponseData
+	BillDownloadUrl string `json:"bill_download_url"`
+}
+
+// TradeBillRow 业务账单明细
+type TradeBillRow struct {
+	TradeNo           string            `bill:"支付宝交易号"`
+	OutTradeNo        string            `bill:"商户订单号"`
+	BizType           string            `bill:"业务类型"`
+	Subject           string            `bill:"商品名称"`
+	CreateTime        string            `bill:"创建时间"`
+	FinishTime        string            `bill:"完成时间"`
+	StoreId           string            `bill:"门店编号"`
+	StoreName         string            `bill:"门店名称"`
+	Operator          string            `bill:"操作员"`
+	TerminalId        string            `bill:"终端号"`
+	BuyerAccount      string            `bill:"对方账户"`
+	TotalAmount       string            `bill:"订单金额"`
+	ReceiptAmount     string            `bill:"商家实收"`
+	AlipayRedPacket   string            `bill:"支付宝红包"`
+	PointAmount       string            `bill:"集分宝"`
+	AlipayDiscount    string            `bill:"支付宝优惠"`
+	MerchantDiscount  string            `bill:"商家优惠"`
+	VoucherAmount     string            `bill:"券核销金额"`
+	VoucherName       string            `bill:"券名称"`
+	MerchantRedPacket string            `bill:"商家红包消费金额"`
+	CardAmount        string            `bill:"卡消费金额"`
+	OutRequestNo      string            `bill:"退款批次号"`
+	ServiceFee        string            `bill:"服务费"`
+	ShareBenefit      string            `bill:"分润"`
+	Remark            string            `bill:"备注"`
+	Columns           map[string]string // 原始列名 => 列值
+}
+
+// SignCustomerBillRow 账务账单明细
+type SignCustomerBillRow struct {
+	AccountLogId    string            `bill:"账务流水号"`
+	BizNo           string            `bill:"业务流水号"`
+	OutTradeNo      string            `bill:"商户订单号"`
+	Subject         string            `bill:"商品名称"`
+	OccurTime       string            `bill:"发生时间"`
+	OppositeAccount string            `bill:"对方账号"`
+	InAmount        string            `bill:"收入金额"`
+	OutAmount       string            `bill:"支出金额"`
+	Balance         string            `bill:"账户余额"`
+	Channel         string            `bill:"交易渠道"`
+	BizType         string            `bill:"业务类型"`
+	Remark          string            `bill:"备注"`
+	Columns         map[string]string // 原始列名 => 列值
+}
+
+// BillSummary 账单汇总
+type BillSummary struct {
+	TradeCount     int      // 交易笔数
+	TradeAmount    string   // 交易商家实收
+	TradeDiscount  string   // 交易商家优惠
+	RefundCount    int      // 退款笔数
+	RefundAmount   string   // 退款商家实收
+	RefundDiscount string   // 退款商家优惠
+	IncomeCount    int      // 收入笔数
+	IncomeAmount   string   // 收入金额
+	ExpenseCount   int      // 支出笔数
+	ExpenseAmount  string   // 支出金额
+	Lines          []string // 账单中的全部说明及合计行
+}
+
+// BillDownloadUrl 查询对账单下载地址，下载地址有效期为30秒
+// @params billType BillType 账单类型
+// @params billDate string 账单时间，日账单格式为 yyyy-MM-dd，月账单格式为 yyyy-MM
+func (pay *AliPay) BillDownloadUrl(ctx context.Context, billType BillType, billDate string) (string, error) {
+	biz, err := json.Marshal(map[string]interface{}{
+		"bill_type": billType,
+		"bill_date": billDate,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	response, err := pay.call(ctx, "alipay.data.dataservice.bill.downloadurl.query", string(biz))
+	if err != nil {
+		return "", err
+	}
+
+	var result billDownloadUrlResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return "", err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return "", err
+	}
+
+	return result.Response.BillDownloadUrl, nil
+}
+
+// DownloadTradeBill 下载业务账单，返回逐行读取账单明细的迭代器，使用完毕后须调用 Close
+// 下载时长由 ctx 控制，不受HTTP客户端超时时间限制
+// @params billDate string 账单时间，日账单格式为 yyyy-MM-dd，月账单格式为 yyyy-MM
+func (pay *AliPay) DownloadTradeBill(ctx context.Context, billDate string) (*TradeBillIterator, error) {
+	reader, err := pay.downloadBill(ctx, BillTypeTrade, billDate)
+	if err != nil {
+		return nil, err
+	}
+	return &TradeBillIterator{billReader: reader}, nil
+}
+
+// DownloadSignCustomerBill 下载账务账单，返回逐行读取账单明细的迭代器，使用完毕后须调用 Close
+// 下载时长由 ctx 控制，不受HTTP客户端超时时间限制
+// @params billDate string 账单时间，日账单格式为 yyyy-MM-dd，月账单格式为 yyyy-MM
+func (pay *AliPay) DownloadSignCustomerBill(ctx context.Context, billDate string) (*SignCustomerBillIterator, error) {
+	reader, err := pay.downloadBill(ctx, BillTypeSignCustomer, billDate)
+	if err != nil {
+		return nil, err
+	}
+	return &SignCustomerBillIterator{billReader: reader}, nil
+}
+
+// TradeBillIterator 业务账单明细迭代器
+type TradeBillIterator struct {
+	*billReader
+	row *TradeBillRow
+}
+
+// Next 读取下一行账单明细，读取完毕或出错时返回false
+func (it *TradeBillIterator) Next() bool {
+	if !it.next() {
+		return false
+	}
+	it.row = &TradeBillRow{Columns: it.columns}
+	fillBillRow(it.row, it.columns)
+	return true
+}
+
+// Row 当前账单明细
+func (it *TradeBillIterator) Row() *TradeBillRow {
+	return it.row
+}
+
+// SignCustomerBillIterator 账务账单明细迭代器
+type SignCustomerBillIterator struct {
+	*billReader
+	row *SignCustomerBillRow
+}
+
+// Next 读取下一行账单明细，读取完毕或出错时返回false
+func (it *SignCustomerBillIterator) Next() bool {
+	if !it.next() {
+		return false
+	}
+	it.row = &SignCustomerBillRow{Columns: it.columns}
+	fillBillRow(it.row, it.columns)
+	return true
+}
+
+// Row 当前账单明细
+func (it *SignCustomerBillIterator) Row() *SignCustomerBillRow {
+	return it.row
+}
+
+// billReader 账单明细文件读取
+type billReader struct {
+	file    *os.File
+	detail  io.ReadCloser
+	reader  *bufio.Reader
+	header  []string
+	columns map[string]string
+	summary BillSummary
+	err     error
+	closed  bool
+}
+
+// Summary 账单汇总，读取完全部明细后可用
+func (r *billReader) Summary() *BillSummary {
+	return &r.summary
+}
+
+// Err 读取过程中发生的错误
+func (r *billReader) Err() error {
+	return r.err
+}
+
+// Close 关闭账单文件并删除下载的临时文件，重复调用时直接返回
+func (r *billReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	if r.detail != nil {
+		_ = r.detail.Close()
+	}
+	_ = r.file.Close()
+	return os.Remove(r.file.Name())
+}
+
+// next 读取下一行明细数据，说明及合计行计入账单汇总
+func (r *billReader) next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	for {
+		line, err := r.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			r.err = err
+			return false
+		}
+		if line == "" && err == io.EOF {
+			return false
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(line) == "" {
+			if err == io.EOF {
+				return false
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			r.summary.add(line)
+			if err == io.EOF {
+				return false
+			}
+			continue
+		}
+
+		csvReader := csv.NewReader(strings.NewReader(line))
+		csvReader.LazyQuotes = true
+		record, csvErr := csvReader.Read()
+		if csvErr != nil {
+			r.err = errors.Wrap(csvErr, "账单明细格式错误")
+			return false
+		}
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+
+		if r.header == nil {
+			r.header = record
+			if err == io.EOF {
+				return false
+			}
+			continue
+		}
+
+		r.columns = make(map[string]string, len(r.header))
+		for i, name := range r.header {
+			if i < len(record) {
+				r.columns[name] = record[i]
+			}
+		}
+		return true
+	}
+}
+
+// add 解析账单说明及合计行
+func (s *BillSummary) add(line string) {
+	s.Lines = append(s.Lines, line)
+
+	matches := billSummaryPattern.FindStringSubmatch(line)
+	if matches == nil {
+		return
+	}
+	count, _ := strconv.Atoi(matches[2])
+	amounts := make([]string, 0, 2)
+	for _, amount := range billAmountPattern.FindAllStringSubmatch(line, -1) {
+		amounts = append(amounts, amount[1])
+	}
+	amount := func(i int) string {
+		if i < len(amounts) {
+			return amounts[i]
+		}
+		return ""
+	}
+
+	switch matches[1] {
+	case "交易":
+		s.TradeCount, s.TradeAmount, s.TradeDiscount = count, amount(0), amount(1)
+	case "退款":
+		s.RefundCount, s.RefundAmount, s.RefundDiscount = count, amount(0), amount(1)
+	case "收入":
+		s.IncomeCount, s.IncomeAmount = count, amount(0)
+	case "支出":
+		s.ExpenseCount, s.ExpenseAmount = count, amount(0)
+	}
+}
+
+// downloadBill 下载账单压缩包至临时文件并打开明细文件
+// 月末账单压缩包较大，下载不受HTTP客户端超时时间限制，由 ctx 控制下载时长
+// @params billType BillType 账单类型
+// @params billDate string 账单时间
+func (pay *AliPay) downloadBill(ctx context.Context, billType BillType, billDate string) (*billReader, error) {
+	billUrl, err := pay.BillDownloadUrl(ctx, billType, billDate)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, billUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := *pay.config.httpClient
+	client.Timeout = 0
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "下载账单失败")
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("下载账单失败: %s", response.Status)
+	}
+
+	file, err := os.CreateTemp("", "alipay-bill-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	reader := &billReader{file: file}
+
+	size, err := io.Copy(file, response.Body)
+	if err != nil {
+		_ = reader.Close()
+		return nil, errors.Wrap(err, "下载账单失败")
+	}
+
+	archive, err := zip.NewReader(file, size)
+	if err != nil {
+		_ = reader.Close()
+		return nil, errors.Wrap(err, "账单压缩包格式错误")
+	}
+
+	detail := billDetailFile(archive)
+	if detail == nil {
+		_ = reader.Close()
+		return nil, errors.New("账单压缩包中未找到明细文件")
+	}
+
+	reader.detail, err = detail.Open()
+	if err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+	reader.reader = bufio.NewReader(support.GbkToUtf8Reader(reader.detail))
+
+	return reader, nil
+}
+
+// billDetailFile 获取账单压缩包中的明细文件，排除汇总文件
+func billDetailFile(archive *zip.Reader) *zip.File {
+	for _, file := range archive.File {
+		name := file.Name
+		if file.NonUTF8 {
+			if utf8Name, err := support.GbkToUtf8([]byte(name)); err == nil {
+				name = string(utf8Name)
+			}
+		}
+		isSummary := strings.Contains(name, "汇总") || strings.Contains(file.Name, "汇总")
+		if strings.HasSuffix(strings.ToLower(file.Name), ".csv") && !isSummary {
+			return file
+		}
+	}
+	return nil
+}
+
+// fillBillRow 按 bill 标签将列值填充至账单明细结构体，列名以标签开头即匹配
+func fillBillRow(row interface{}, columns map[string]string) {
+	value := reflect.ValueOf(row).Elem()
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("bill")
+		if tag == "" {
+			continue
+		}
+		for name, column := range columns {
+			if strings.HasPrefix(name, tag) {
+				value.Field(i).SetString(column)
+				break
+			}
+		}
+	}
+}
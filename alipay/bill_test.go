@@ -0,0 +1,24 @@
+package alipay
+
+import (
+	"os"
+	"testing"
+)
+
+func TestBillReaderClose(t *testing.T) {
+	file, err := os.CreateTemp("", "alipay-bill-*.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := &billReader{file: file}
+
+	if err = reader.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err = os.Stat(file.Name()); !os.IsNotExist(err) {
+		t.Fatalf("Close() temp file not removed, stat error = %v", err)
+	}
+	if err = reader.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+}
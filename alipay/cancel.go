@@ -0,0 +1,101 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	cancelMaxRetry     = 5               // 撤销最大重试次数
+	cancelRetryBackoff = 1 * time.Second // 撤销首次重试间隔，之后每次翻倍
+)
+
+// CancelAction 撤销触发的动作
+type CancelAction string
+
+const (
+	CancelActionClose  CancelAction = "close"  // 交易未支付，触发关闭交易动作，无退款
+	CancelActionRefund CancelAction = "refund" // 交易已支付，触发交易退款动作
+)
+
+// cancelResponse 交易撤销响应参数
+type cancelResponse struct {
+	PayResponse
+	Response CancelResponseData `json:"alipay_trade_cancel_response"`
+}
+
+// CancelResponseData 交易撤销响应参数数据
+type CancelResponseData struct {
+	PayResponseData
+	TradeNo            string       `json:"trade_no"`
+	OutTradeNo         string       `json:"out_trade_no"`
+	RetryFlag          string       `json:"retry_flag"` // 是否需要重试 Y/N
+	Action             CancelAction `json:"action"`
+	GmtRefundPay       string       `json:"gmt_refund_pay"`
+	RefundSettlementId string       `json:"refund_settlement_id"`
+}
+
+// Cancel 统一收单交易撤销
+// 支付交易返回失败或支付系统超时时调用，支付宝返回 retry_flag=Y 或系统错误时按退避间隔重试，
+// 直至交易被关闭或退款，超过最大重试次数或 ctx 取消后返回最后一次的错误
+// @params outTradeNo string 商户订单号，与tradeNo二选一
+// @params tradeNo string 支付宝交易号，与outTradeNo二选一
+func (pay *AliPay) Cancel(ctx context.Context, outTradeNo, tradeNo string) (*CancelResponseData, error) {
+	if outTradeNo == "" && tradeNo == "" {
+		return nil, errors.New("out_trade_no 和 trade_no 不能同时为空")
+	}
+
+	bizContent := map[string]interface{}{}
+	if outTradeNo != "" {
+		bizContent["out_trade_no"] = outTradeNo
+	}
+	if tradeNo != "" {
+		bizContent["trade_no"] = tradeNo
+	}
+
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := cancelRetryBackoff
+	for i := 0; ; i++ {
+		var data *CancelResponseData
+		var retry bool
+		data, retry, err = pay.cancel(ctx, string(biz))
+		if !retry || i >= cancelMaxRetry {
+			return data, err
+		}
+
+		if sleepErr := sleep(ctx, backoff); sleepErr != nil {
+			return nil, errors.Wrapf(sleepErr, "撤销重试中断，最后一次错误: %v", err)
+		}
+		backoff *= 2
+	}
+}
+
+// cancel 发起一次撤销请求
+// @params bizContent string 业务数据
+// @return retry bool 是否需要重试
+func (pay *AliPay) cancel(ctx context.Context, bizContent string) (data *CancelResponseData, retry bool, err error) {
+	response, err := pay.call(ctx, "alipay.trade.cancel", bizContent)
+	if err != nil {
+		// 仅网络异常无法确定撤销结果时重试，签名、验签等错误重试不会成功
+		return nil, IsRequestError(err), err
+	}
+
+	var result cancelResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, result.Response.RetryFlag == "Y" || IsRetryable(err), err
+	}
+
+	return &result.Response, false, nil
+}
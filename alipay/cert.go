@@ -0,0 +1,345 @@
+package alipay
+
+import (
+	"context"
+	"crypto/md5"
+	cryptoRsa "crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// certDownloadMethod 支付宝公钥证书下载接口
+const certDownloadMethod = "alipay.open.app.alipaycert.download"
+
+const (
+	certDownloadInterval   = time.Minute      // 两次下载支付宝公钥证书的最小间隔
+	certDownloadFailureTTL = 10 * time.Minute // 下载失败的证书序列号在该时间内不再下载
+)
+
+// certSnPattern 证书序列号格式，MD5十六进制字符串
+var certSnPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// alipayCertDownloadResponse 支付宝公钥证书下载响应参数
+type alipayCertDownloadResponse struct {
+	PayResponse
+	Response alipayCertDownloadResponseData `json:"alipay_open_app_alipaycert_download_response"`
+}
+
+// alipayCertDownloadResponseData 支付宝公钥证书下载响应参数数据
+type alipayCertDownloadResponseData struct {
+	PayResponseData
+	AlipayCertContent string `json:"alipay_cert_content"`
+}
+
+// certStore 公钥证书模式下的证书信息
+type certStore struct {
+	appCertSn        string                          // 应用公钥证书序列号
+	alipayRootCertSn string                          // 支付宝根证书序列号
+	alipayCertSn     string                          // 当前支付宝公钥证书序列号
+	alipayCerts      map[string]*cryptoRsa.PublicKey // 支付宝公钥证书序列号 => 支付宝公钥
+	lock             sync.RWMutex
+
+	// 回调中的 alipay_cert_sn 未经验证，证书下载须串行执行并限制频率
+	downloadLock  sync.Mutex
+	lastDownload  time.Time            // 最近一次下载时间
+	failedCertSns map[string]time.Time // 下载失败的证书序列号 => 失败时间
+}
+
+// isCertMode 是否为公钥证书模式
+func (pay *AliPay) isCertMode() bool {
+	return pay.cert != nil
+}
+
+// loadCert 加载应用公钥证书、支付宝公钥证书及支付宝根证书，计算证书序列号
+func (pay *AliPay) loadCert() error {
+	c := pay.config
+	if c.appCertPath != "" {
+		if err := readFile(c.appCertPath, &c.appCert); err != nil {
+			return errors.Wrap(err, "读取应用公钥证书失败")
+		}
+	}
+	if c.alipayCertPath != "" {
+		if err := readFile(c.alipayCertPath, &c.alipayCert); err != nil {
+			return errors.Wrap(err, "读取支付宝公钥证书失败")
+		}
+	}
+	if c.alipayRootCertPath != "" {
+		if err := readFile(c.alipayRootCertPath, &c.alipayRootCert); err != nil {
+			return errors.Wrap(err, "读取支付宝根证书失败")
+		}
+	}
+
+	if c.appCert == "" && c.alipayCert == "" && c.alipayRootCert == "" {
+		return nil
+	}
+	if c.appCert == "" || c.alipayCert == "" || c.alipayRootCert == "" {
+		return errors.New("公钥证书模式需同时设置应用公钥证书、支付宝公钥证书及支付宝根证书")
+	}
+
+	appCert, err := parseCert([]byte(c.appCert))
+	if err != nil {
+		return errors.Wrap(err, "应用公钥证书错误")
+	}
+
+	alipayCert, err := parseCert([]byte(c.alipayCert))
+	if err != nil {
+		return errors.Wrap(err, "支付宝公钥证书错误")
+	}
+	alipayPublicKey, err := certPublicKey(alipayCert)
+	if err != nil {
+		return errors.Wrap(err, "支付宝公钥证书错误")
+	}
+
+	rootCertSn, err := rootCertSN([]byte(c.alipayRootCert))
+	if err != nil {
+		return errors.Wrap(err, "支付宝根证书错误")
+	}
+
+	alipayCertSn := certSN(alipayCert)
+	pay.cert = &certStore{
+		appCertSn:        certSN(appCert),
+		alipayRootCertSn: rootCertSn,
+		alipayCertSn:     alipayCertSn,
+		alipayCerts: map[string]*cryptoRsa.PublicKey{
+			alipayCertSn: alipayPublicKey,
+		},
+	}
+
+	return nil
+}
+
+// alipayPublicKeyFor 获取验签使用的支付宝公钥
+// 公钥证书模式下按 alipay_cert_sn 选择对应证书，支付宝证书轮换后可自动下载新证书
+// 回调等未经认证的来源只能使用已知证书，避免伪造的序列号触发下载并占用下载频率
+// @params certSn string 支付宝公钥证书序列号，为空时使用当前证书
+// @params download bool 序列号未知时是否下载证书，仅网关同步响应验签时允许
+func (pay *AliPay) alipayPublicKeyFor(ctx context.Context, certSn string, download bool) (*cryptoRsa.PublicKey, error) {
+	if !pay.isCertMode() {
+		return pay.alipayPublicKey, nil
+	}
+
+	pay.cert.lock.RLock()
+	if certSn == "" {
+		certSn = pay.cert.alipayCertSn
+	}
+	publicKey, ok := pay.cert.alipayCerts[certSn]
+	pay.cert.lock.RUnlock()
+	if ok {
+		return publicKey, nil
+	}
+	if !download {
+		return nil, errors.Errorf("未知的支付宝公钥证书序列号: %s", certSn)
+	}
+
+	return pay.downloadAlipayCertOnce(ctx, certSn)
+}
+
+// downloadAlipayCertOnce 串行下载未知序列号的支付宝公钥证书
+// 同一序列号并发请求只下载一次，下载失败的序列号在一段时间内直接返回错误，且两次下载间隔不小于 certDownloadInterval
+// @params certSn string 支付宝公钥证书序列号
+func (pay *AliPay) downloadAlipayCertOnce(ctx context.Context, certSn string) (*cryptoRsa.PublicKey, error) {
+	if !certSnPattern.MatchString(certSn) {
+		return nil, errors.Errorf("支付宝公钥证书序列号格式错误: %s", certSn)
+	}
+
+	store := pay.cert
+	store.downloadLock.Lock()
+	defer store.downloadLock.Unlock()
+
+	// 等待期间其他请求可能已完成下载
+	store.lock.RLock()
+	publicKey, ok := store.alipayCerts[certSn]
+	store.lock.RUnlock()
+	if ok {
+		return publicKey, nil
+	}
+
+	now := time.Now()
+	if failedAt, ok := store.failedCertSns[certSn]; ok && now.Sub(failedAt) < certDownloadFailureTTL {
+		return nil, errors.Errorf("支付宝公钥证书序列号 %s 下载失败，暂不重试", certSn)
+	}
+	if now.Sub(store.lastDownload) < certDownloadInterval {
+		return nil, errors.Errorf("支付宝公钥证书下载过于频繁，未知序列号: %s", certSn)
+	}
+	store.lastDownload = now
+
+	publicKey, err := pay.downloadAlipayCert(ctx, certSn)
+	if err != nil {
+		if store.failedCertSns == nil {
+			store.failedCertSns = make(map[string]time.Time)
+		}
+		for sn, failedAt := range store.failedCertSns {
+			if now.Sub(failedAt) >= certDownloadFailureTTL {
+				delete(store.failedCertSns, sn)
+			}
+		}
+		store.failedCertSns[certSn] = now
+		return nil, err
+	}
+
+	return publicKey, nil
+}
+
+// downloadAlipayCert 下载指定序列号的支付宝公钥证书，校验证书链后缓存并作为当前证书
+// @params certSn string 支付宝公钥证书序列号
+func (pay *AliPay) downloadAlipayCert(ctx context.Context, certSn string) (*cryptoRsa.PublicKey, error) {
+	biz, err := json.Marshal(map[string]interface{}{
+		"alipay_cert_sn": certSn,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, certDownloadMethod, string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result alipayCertDownloadResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, errors.Wrap(err, "下载支付宝公钥证书失败")
+	}
+
+	content, err := base64.StdEncoding.DecodeString(result.Response.AlipayCertContent)
+	if err != nil {
+		return nil, errors.Wrap(err, "支付宝公钥证书内容错误")
+	}
+
+	cert, err := pay.verifyAlipayCert(content)
+	if err != nil {
+		return nil, err
+	}
+	if sn := certSN(cert); sn != certSn {
+		return nil, errors.Errorf("支付宝公钥证书序列号不匹配: %s != %s", sn, certSn)
+	}
+
+	publicKey, err := certPublicKey(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	pay.cert.lock.Lock()
+	pay.cert.alipayCerts[certSn] = publicKey
+	pay.cert.alipayCertSn = certSn
+	pay.cert.lock.Unlock()
+
+	return publicKey, nil
+}
+
+// verifyAlipayCert 使用支付宝根证书校验支付宝公钥证书链
+// @params content []byte 支付宝公钥证书内容，首个证书为公钥证书，其余为中间证书
+func (pay *AliPay) verifyAlipayCert(content []byte) (*x509.Certificate, error) {
+	certs := parseCerts(content)
+	if len(certs) == 0 {
+		return nil, errors.New("支付宝公钥证书内容错误")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	roots := x509.NewCertPool()
+	for _, cert := range parseCerts([]byte(pay.config.alipayRootCert)) {
+		roots.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "支付宝公钥证书不受信任")
+	}
+
+	return certs[0], nil
+}
+
+// certSN 计算证书序列号，MD5(签发机构DN + 证书序列号)
+func certSN(cert *x509.Certificate) string {
+	sum := md5.Sum([]byte(cert.Issuer.String() + cert.SerialNumber.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// rootCertSN 计算支付宝根证书序列号，仅计算RSA签名算法的证书，以下划线拼接
+func rootCertSN(content []byte) (string, error) {
+	sns := make([]string, 0)
+	for _, cert := range parseCerts(content) {
+		if cert.SignatureAlgorithm == x509.SHA1WithRSA || cert.SignatureAlgorithm == x509.SHA256WithRSA {
+			sns = append(sns, certSN(cert))
+		}
+	}
+	if len(sns) == 0 {
+		return "", errors.New("未找到RSA根证书")
+	}
+
+	return strings.Join(sns, "_"), nil
+}
+
+// parseCert 解析证书内容中的第一个证书
+func parseCert(content []byte) (*x509.Certificate, error) {
+	certs := parseCerts(content)
+	if len(certs) == 0 {
+		return nil, errors.New("cert error")
+	}
+	return certs[0], nil
+}
+
+// parseCerts 解析证书内容中的全部证书，跳过无法解析的证书（如国密证书）
+func parseCerts(content []byte) []*x509.Certificate {
+	certs := make([]*x509.Certificate, 0)
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+	}
+
+	return certs
+}
+
+// certPublicKey 获取证书中的公钥
+func certPublicKey(cert *x509.Certificate) (*cryptoRsa.PublicKey, error) {
+	publicKey, ok := cert.PublicKey.(*cryptoRsa.PublicKey)
+	if !ok {
+		return nil, errors.New("证书公钥不是RSA公钥")
+	}
+
+	return publicKey, nil
+}
+
+// readFile 读取文件内容
+func readFile(path string, content *string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	*content = string(data)
+	return nil
+}
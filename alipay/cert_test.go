@@ -0,0 +1,30 @@
+package alipay
+
+import (
+	"context"
+	cryptoRsa "crypto/rsa"
+	"testing"
+)
+
+func TestAlipayPublicKeyForCallback(t *testing.T) {
+	pay, key := newTestAliPay(t)
+	pay.cert = &certStore{
+		alipayCertSn: "0123456789abcdef0123456789abcdef",
+		alipayCerts: map[string]*cryptoRsa.PublicKey{
+			"0123456789abcdef0123456789abcdef": &key.PublicKey,
+		},
+	}
+
+	publicKey, err := pay.alipayPublicKeyFor(context.Background(), "0123456789abcdef0123456789abcdef", false)
+	if err != nil || publicKey != &key.PublicKey {
+		t.Fatalf("alipayPublicKeyFor() = %v, error = %v", publicKey, err)
+	}
+
+	// 回调中的未知序列号不得触发下载，也不占用下载频率
+	if _, err = pay.alipayPublicKeyFor(context.Background(), "fedcba9876543210fedcba9876543210", false); err == nil {
+		t.Fatal("alipayPublicKeyFor() with unknown certSn want error")
+	}
+	if !pay.cert.lastDownload.IsZero() || len(pay.cert.failedCertSns) != 0 {
+		t.Fatal("alipayPublicKeyFor() must not download for callbacks")
+	}
+}
@@ -0,0 +1,104 @@
+package alipay
+
+import (
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/dysodeng/payment/support"
+	"github.com/pkg/errors"
+)
+
+const (
+	CharsetUtf8 = "utf-8" // UTF-8编码
+	CharsetGbk  = "gbk"   // GBK编码
+)
+
+// checkCharset 校验请求编码
+func (pay *AliPay) checkCharset() error {
+	switch strings.ToLower(pay.config.charset) {
+	case CharsetUtf8, CharsetGbk:
+		pay.config.charset = strings.ToLower(pay.config.charset)
+		return nil
+	default:
+		return errors.Errorf("不支持的编码: %s", pay.config.charset)
+	}
+}
+
+// isGbk 是否为GBK编码，GB2312及GB18030按GBK处理
+func isGbk(charset string) bool {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "gbk", "gb2312", "gb18030":
+		return true
+	default:
+		return false
+	}
+}
+
+// contentTypeCharset 获取 Content-Type 中声明的编码，未声明时返回空字符串
+func contentTypeCharset(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return params["charset"]
+}
+
+// toUtf8 将指定编码的内容转换为UTF-8，非GBK编码时原样返回
+func toUtf8(data []byte, charset string) ([]byte, error) {
+	if !isGbk(charset) {
+		return data, nil
+	}
+	return support.GbkToUtf8(data)
+}
+
+// fromUtf8 将UTF-8内容转换为指定编码，非GBK编码时原样返回
+func fromUtf8(data string, charset string) (string, error) {
+	if !isGbk(charset) {
+		return data, nil
+	}
+	encoded, err := support.Utf8ToGbk([]byte(data))
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// encodeParams 将请求参数值转换为指定编码
+func encodeParams(params map[string]string, charset string) (map[string]string, error) {
+	if !isGbk(charset) {
+		return params, nil
+	}
+
+	result := make(map[string]string, len(params))
+	for key, value := range params {
+		encoded, err := fromUtf8(value, charset)
+		if err != nil {
+			return nil, errors.Wrapf(err, "参数 %s 编码转换失败", key)
+		}
+		result[key] = encoded
+	}
+	return result, nil
+}
+
+// valuesToUtf8 将指定编码的回调参数转换为UTF-8，非GBK编码时原样返回
+func valuesToUtf8(values url.Values, charset string) (url.Values, error) {
+	if !isGbk(charset) {
+		return values, nil
+	}
+
+	result := make(url.Values, len(values))
+	for key, items := range values {
+		for _, item := range items {
+			value, err := toUtf8([]byte(item), charset)
+			if err != nil {
+				return nil, err
+			}
+			result.Add(key, string(value))
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// closeResponse 交易关闭响应参数
+type closeResponse struct {
+	PayResponse
+	Response CloseResponseData `json:"alipay_trade_close_response"`
+}
+
+// CloseResponseData 交易关闭响应参数数据
+type CloseResponseData struct {
+	PayResponseData
+	TradeNo    string `json:"trade_no"`
+	OutTradeNo string `json:"out_trade_no"`
+}
+
+// Close 统一收单交易关闭，用于关闭未付款的交易
+// @params outTradeNo string 商户订单号，与tradeNo二选一
+// @params tradeNo string 支付宝交易号，与outTradeNo二选一
+// @params operatorId string 商家操作员编号，可为空
+func (pay *AliPay) Close(ctx context.Context, outTradeNo, tradeNo, operatorId string) (*CloseResponseData, error) {
+	if outTradeNo == "" && tradeNo == "" {
+		return nil, errors.New("out_trade_no 和 trade_no 不能同时为空")
+	}
+
+	bizContent := map[string]interface{}{}
+	if outTradeNo != "" {
+		bizContent["out_trade_no"] = outTradeNo
+	}
+	if tradeNo != "" {
+		bizContent["trade_no"] = tradeNo
+	}
+	if operatorId != "" {
+		bizContent["operator_id"] = operatorId
+	}
+
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.trade.close", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result closeResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
@@ -0,0 +1,87 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// createResponse 统一收单交易创建响应参数
+type createResponse struct {
+	PayResponse
+	Response CreateResponseData `json:"alipay_trade_create_response"`
+}
+
+// CreateResponseData 统一收单交易创建响应参数数据
+type CreateResponseData struct {
+	PayResponseData
+	OutTradeNo string `json:"out_trade_no"`
+	TradeNo    string `json:"trade_no"` // 小程序调用 my.tradePay 所需的支付宝交易号
+}
+
+// TradeCreateRequest 统一收单交易创建请求参数
+type TradeCreateRequest struct {
+	OutTradeNo     string          `json:"out_trade_no" validate:"required,max=64"`
+	TotalAmount    string          `json:"total_amount" validate:"required,amount"`
+	Subject        string          `json:"subject" validate:"required,max=256"`
+	BuyerId        string          `json:"buyer_id,omitempty" validate:"max=28"`
+	BuyerOpenId    string          `json:"buyer_open_id,omitempty" validate:"max=128"`
+	ProductCode    string          `json:"product_code,omitempty" validate:"max=64"`
+	OpAppId        string          `json:"op_app_id,omitempty" validate:"max=32"`
+	SellerId       string          `json:"seller_id,omitempty" validate:"max=28"`
+	Body           string          `json:"body,omitempty" validate:"max=128"`
+	GoodsDetail    []GoodsDetail   `json:"goods_detail,omitempty"`
+	ExtendParams   *ExtendParams   `json:"extend_params,omitempty"`
+	BusinessParams *BusinessParams `json:"business_params,omitempty"`
+	OperatorId     string          `json:"operator_id,omitempty" validate:"max=28"`
+	StoreId        string          `json:"store_id,omitempty" validate:"max=32"`
+	TerminalId     string          `json:"terminal_id,omitempty" validate:"max=32"`
+	TimeoutExpress string          `json:"timeout_express,omitempty" validate:"max=6"`
+	TimeExpire     string          `json:"time_expire,omitempty" validate:"max=32"`
+	QueryOptions   []string        `json:"query_options,omitempty"`
+	SettleInfo     *SettleInfo     `json:"settle_info,omitempty"`
+}
+
+// Validate 校验统一收单交易创建请求参数
+func (req *TradeCreateRequest) Validate() error {
+	return validateFields(req)
+}
+
+// Create 统一收单交易创建（小程序支付）
+// 业务数据中须包含买家支付宝用户ID buyer_id 或 buyer_open_id
+// @params bizContent interface{} 业务数据，*TradeCreateRequest 或 map[string]interface{}
+func (pay *AliPay) Create(ctx context.Context, bizContent interface{}) (*CreateResponseData, error) {
+	m, err := bizMap(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	buyerId, _ := m["buyer_id"].(string)
+	buyerOpenId, _ := m["buyer_open_id"].(string)
+	if buyerId == "" && buyerOpenId == "" {
+		return nil, errors.New("buyer_id 和 buyer_open_id 不能同时为空")
+	}
+
+	biz, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.trade.create", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result createResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
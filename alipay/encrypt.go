@@ -0,0 +1,160 @@
+package alipay
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/dysodeng/payment/support/crypto/aes"
+	"github.com/dysodeng/payment/support/crypto/rsa"
+	"github.com/pkg/errors"
+)
+
+// encryptType 接口内容加密方式
+const encryptType = "AES"
+
+// aesIv 接口内容加密使用全零初始向量
+var aesIv = make([]byte, 16)
+
+// PhoneNumber 小程序用户手机号
+type PhoneNumber struct {
+	PayResponseData
+	Mobile string `json:"mobile"`
+}
+
+// encryptedContent 小程序端 my.getPhoneNumber 返回的加密内容
+type encryptedContent struct {
+	Response    string `json:"response"`
+	Sign        string `json:"sign"`
+	SignType    string `json:"sign_type"`
+	EncryptType string `json:"encrypt_type"`
+	Charset     string `json:"charset"`
+}
+
+// checkEncryptKey 校验接口内容加密密钥
+func (pay *AliPay) checkEncryptKey() error {
+	if pay.config.encryptKey == "" {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(pay.config.encryptKey)
+	if err != nil {
+		return errors.Wrap(err, "接口内容加密密钥须为base64编码")
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return errors.Errorf("接口内容加密密钥长度错误: %d", len(key))
+	}
+}
+
+// encrypt 加密接口内容
+// @params content string 原始内容
+func (pay *AliPay) encrypt(content string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(pay.config.encryptKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := aes.CBCEncrypt([]byte(content), key, aesIv)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt 解密接口内容，须使用 WithEncryptKey 设置接口内容加密密钥
+// @params content string 加密内容，base64编码
+func (pay *AliPay) Decrypt(content string) ([]byte, error) {
+	if pay.config.encryptKey == "" {
+		return nil, errors.New("未设置接口内容加密密钥")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(pay.config.encryptKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, errors.Wrap(err, "加密内容须为base64编码")
+	}
+
+	plaintext, err := aes.CBCDecrypt(ciphertext, key, aesIv)
+	if err != nil {
+		return nil, errors.Wrap(err, "接口内容解密失败")
+	}
+
+	return plaintext, nil
+}
+
+// decryptResponse 解密已验签的 <method>_response 节点，返回明文JSON
+// 加密响应的节点值为JSON字符串，验签内容为包含引号的原始字符串，须在验签后解密
+// @params method string 接口方法
+// @params node []byte 已验签的响应节点
+func (pay *AliPay) decryptResponse(method string, node []byte) ([]byte, error) {
+	if pay.config.encryptKey == "" || len(node) == 0 || node[0] != '"' {
+		return node, nil
+	}
+
+	var content string
+	if err := json.Unmarshal(node, &content); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := pay.Decrypt(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", method)
+	}
+
+	return plaintext, nil
+}
+
+// DecryptPhoneNumber 验签并解密小程序 my.getPhoneNumber 返回的手机号加密数据
+// @params content string 小程序端 my.getPhoneNumber 返回的 response 完整JSON字符串
+func (pay *AliPay) DecryptPhoneNumber(ctx context.Context, content string) (*PhoneNumber, error) {
+	var encrypted encryptedContent
+	if err := json.Unmarshal([]byte(content), &encrypted); err != nil {
+		return nil, errors.Wrap(err, "加密数据格式错误")
+	}
+	if encrypted.Response == "" {
+		return nil, errors.New("加密数据缺少 response")
+	}
+
+	// 加密数据由小程序客户端上送，不可信，必须验签
+	if encrypted.Sign == "" {
+		return nil, errors.Wrap(ErrResponseSign, "手机号加密数据缺少签名")
+	}
+	if encrypted.SignType != "" && encrypted.SignType != "RSA2" {
+		return nil, errors.Errorf("不支持的签名类型: %s", encrypted.SignType)
+	}
+
+	publicKey, err := pay.alipayPublicKeyFor(ctx, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	// 待验签内容为包含双引号的加密字符串
+	ok, err := rsa.CheckWithKey(`"`+encrypted.Response+`"`, encrypted.Sign, publicKey)
+	if err != nil || !ok {
+		return nil, errors.Wrap(ErrResponseSign, "手机号加密数据验签失败")
+	}
+
+	plaintext, err := pay.Decrypt(encrypted.Response)
+	if err != nil {
+		return nil, err
+	}
+
+	var phone PhoneNumber
+	if err = json.Unmarshal(plaintext, &phone); err != nil {
+		return nil, err
+	}
+
+	if err = phone.err(plaintext); err != nil {
+		return nil, err
+	}
+
+	return &phone, nil
+}
@@ -0,0 +1,113 @@
+package alipay
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	codeBusinessFailure = "40004" // 业务处理失败
+
+	subCodeTradeNotExist   = "ACQ.TRADE_NOT_EXIST"   // 交易不存在
+	subCodeTradeHasSuccess = "ACQ.TRADE_HAS_SUCCESS" // 交易已被支付
+)
+
+// retryableSubCodes 可使用相同参数重试的业务返回码
+var retryableSubCodes = map[string]bool{
+	"ACQ.SYSTEM_ERROR": true,
+	"SYSTEM_ERROR":     true,
+	"aop.SYSTEM_ERROR": true,
+	"isp.unknow-error": true,
+}
+
+// Error 支付宝接口业务错误
+type Error struct {
+	Code    string // 网关返回码
+	Msg     string // 网关返回码描述
+	SubCode string // 业务返回码
+	SubMsg  string // 业务返回码描述
+	Raw     string // 原始响应内容
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("msg: %s, code:%s, sub_code:%s, sub_msg:%s", e.Msg, e.Code, e.SubCode, e.SubMsg)
+}
+
+// RequestError 请求支付宝网关失败（网络异常、超时等），接口处理结果未知
+type RequestError struct {
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return "请求支付宝接口失败: " + e.Err.Error()
+}
+
+// Unwrap 返回原始错误，可使用 errors.Is 判断 context.Canceled 等
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable 是否为系统繁忙等处理结果未知的错误，可使用相同参数重试
+func (e *Error) IsRetryable() bool {
+	return e.Code == systemErrorCode || retryableSubCodes[e.SubCode]
+}
+
+// IsTradeNotExist 是否为交易不存在
+func (e *Error) IsTradeNotExist() bool {
+	return e.SubCode == subCodeTradeNotExist
+}
+
+// IsTradeHasSuccess 是否为交易已被支付
+func (e *Error) IsTradeHasSuccess() bool {
+	return e.SubCode == subCodeTradeHasSuccess
+}
+
+// IsBusinessFailure 是否为业务处理失败，该类错误使用相同参数重试不会成功
+func (e *Error) IsBusinessFailure() bool {
+	return e.Code == codeBusinessFailure && !e.IsRetryable()
+}
+
+// AsError 获取错误链中的支付宝接口业务错误
+func AsError(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// IsRetryable 错误是否可使用相同参数重试
+func IsRetryable(err error) bool {
+	e, ok := AsError(err)
+	return ok && e.IsRetryable()
+}
+
+// IsRequestError 错误是否为请求支付宝网关失败
+func IsRequestError(err error) bool {
+	var e *RequestError
+	return errors.As(err, &e)
+}
+
+// isResultUnknown 错误是否表示接口处理结果未知，须查询确认结果
+func isResultUnknown(err error) bool {
+	return IsRetryable(err) || IsRequestError(err)
+}
+
+// IsTradeNotExist 错误是否为交易不存在
+func IsTradeNotExist(err error) bool {
+	e, ok := AsError(err)
+	return ok && e.IsTradeNotExist()
+}
+
+// IsTradeHasSuccess 错误是否为交易已被支付
+func IsTradeHasSuccess(err error) bool {
+	e, ok := AsError(err)
+	return ok && e.IsTradeHasSuccess()
+}
+
+// IsBusinessFailure 错误是否为业务处理失败
+func IsBusinessFailure(err error) bool {
+	e, ok := AsError(err)
+	return ok && e.IsBusinessFailure()
+}
@@ -0,0 +1,47 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Execute 通用接口调用，用于调用未封装的支付宝开放接口
+// 完成签名、请求及响应验签，并将 <method>_response 节点解析至 out
+// @params method string 接口方法，如 alipay.trade.query
+// @params bizContent interface{} 业务数据，请求结构体、map[string]interface{}，无业务数据时为nil
+// @params out interface{} 响应节点解析目标，为nil时仅校验响应码
+func (pay *AliPay) Execute(ctx context.Context, method string, bizContent interface{}, out interface{}) error {
+	biz, err := marshalBiz(bizContent)
+	if err != nil {
+		return err
+	}
+
+	response, err := pay.call(ctx, method, biz)
+	if err != nil {
+		return err
+	}
+
+	node, err := responseNode(response, responseNodeName(method), CharsetUtf8)
+	if err != nil {
+		return err
+	}
+
+	var data PayResponseData
+	err = json.Unmarshal(node, &data)
+	if err != nil {
+		return err
+	}
+
+	// 部分接口（如 alipay.system.oauth.token）成功响应不返回 code，失败时通过 error_response 节点或非空 code 返回
+	if data.Code != "" {
+		if err = data.err(response); err != nil {
+			return err
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(node, out)
+}
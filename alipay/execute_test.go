@@ -0,0 +1,45 @@
+package alipay
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	_, key := newTestAliPay(t)
+
+	tests := []struct {
+		name string
+		node string
+		err  bool
+	}{
+		{name: "success", node: `{"code":"10000","msg":"Success","user_id":"2088102150477652"}`},
+		{name: "success without code", node: `{"user_id":"2088102150477652","access_token":"token"}`},
+		{name: "business failure", node: `{"code":"40004","msg":"Business Failed","sub_code":"isv.code-invalid"}`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"alipay_system_oauth_token_response":` + tt.node + `,"sign":"` + signTest(t, key, tt.node) + `"}`
+			pay, _ := newTestAliPay(t, gatewayResponse(body))
+			pay.alipayPublicKey = &key.PublicKey
+
+			var out struct {
+				UserId string `json:"user_id"`
+			}
+			err := pay.Execute(context.Background(), "alipay.system.oauth.token", nil, &out)
+			if tt.err {
+				if _, ok := AsError(err); !ok {
+					t.Fatalf("Execute() error = %v, want *Error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if out.UserId != "2088102150477652" {
+				t.Fatalf("Execute() out = %+v", out)
+			}
+		})
+	}
+}
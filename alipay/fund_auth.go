@@ -0,0 +1,358 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	fundAuthProductCode    = "PRE_AUTH_ONLINE" // 线上资金预授权产品码
+	fundAuthOfflineCode    = "PRE_AUTH"        // 线下资金预授权产品码
+	fundAuthPayProductCode = "PREAUTH_PAY"     // 预授权转支付产品码
+)
+
+// FundAuthOrderStatus 资金授权订单状态
+type FundAuthOrderStatus string
+
+const (
+	FundAuthOrderStatusInit       FundAuthOrderStatus = "INIT"       // 初始
+	FundAuthOrderStatusAuthorized FundAuthOrderStatus = "AUTHORIZED" // 已授权
+	FundAuthOrderStatusFinish     FundAuthOrderStatus = "FINISH"     // 完成，全部解冻或转支付
+	FundAuthOrderStatusClosed     FundAuthOrderStatus = "CLOSED"     // 关闭
+)
+
+// FundAuthOperationStatus 资金操作流水状态
+type FundAuthOperationStatus string
+
+const (
+	FundAuthOperationStatusInit    FundAuthOperationStatus = "INIT"    // 初始
+	FundAuthOperationStatusSuccess FundAuthOperationStatus = "SUCCESS" // 成功
+	FundAuthOperationStatusClosed  FundAuthOperationStatus = "CLOSED"  // 关闭
+)
+
+// FundAuthFreezeRequest 资金授权冻结请求参数，用于App冻结及线下发码冻结
+type FundAuthFreezeRequest struct {
+	OutOrderNo         string `json:"out_order_no" validate:"required,max=64"`
+	OutRequestNo       string `json:"out_request_no" validate:"required,max=64"`
+	OrderTitle         string `json:"order_title" validate:"required,max=100"`
+	Amount             string `json:"amount" validate:"required,amount"`
+	ProductCode        string `json:"product_code,omitempty" validate:"max=32"`
+	PayeeUserId        string `json:"payee_user_id,omitempty" validate:"max=32"`
+	PayeeLogonId       string `json:"payee_logon_id,omitempty" validate:"max=100"`
+	PayTimeout         string `json:"pay_timeout,omitempty" validate:"max=5"`
+	TimeoutExpress     string `json:"timeout_express,omitempty" validate:"max=5"`
+	ExtraParam         string `json:"extra_param,omitempty" validate:"max=300"`
+	SceneCode          string `json:"scene_code,omitempty" validate:"max=64"`
+	TransCurrency      string `json:"trans_currency,omitempty" validate:"max=8"`
+	SettleCurrency     string `json:"settle_currency,omitempty" validate:"max=8"`
+	DepositProductMode string `json:"deposit_product_mode,omitempty" validate:"max=32"` // DEPOSIT_ONLY 支付宝预授权，POSTPAY 信用预授权
+}
+
+// Validate 校验资金授权冻结请求参数
+func (req *FundAuthFreezeRequest) Validate() error {
+	return validateFields(req)
+}
+
+// FundAuthUnfreezeRequest 资金授权解冻请求参数
+type FundAuthUnfreezeRequest struct {
+	AuthNo       string `json:"auth_no" validate:"required,max=64"`
+	OutRequestNo string `json:"out_request_no" validate:"required,max=64"`
+	Amount       string `json:"amount" validate:"required,amount"`
+	Remark       string `json:"remark" validate:"required,max=100"`
+	ExtraParam   string `json:"extra_param,omitempty" validate:"max=300"`
+}
+
+// Validate 校验资金授权解冻请求参数
+func (req *FundAuthUnfreezeRequest) Validate() error {
+	return validateFields(req)
+}
+
+// FundAuthTradePayRequest 预授权转支付请求参数
+type FundAuthTradePayRequest struct {
+	OutTradeNo      string        `json:"out_trade_no" validate:"required,max=64"`
+	TotalAmount     string        `json:"total_amount" validate:"required,amount"`
+	Subject         string        `json:"subject" validate:"required,max=256"`
+	AuthNo          string        `json:"auth_no" validate:"required,max=64"`
+	ProductCode     string        `json:"product_code,omitempty" validate:"max=32"`
+	AuthConfirmMode string        `json:"auth_confirm_mode,omitempty" validate:"max=32"` // COMPLETE 转支付后解冻剩余金额，NOT_COMPLETE 不解冻（支付宝默认）
+	BuyerId         string        `json:"buyer_id,omitempty" validate:"max=28"`
+	BuyerOpenId     string        `json:"buyer_open_id,omitempty" validate:"max=128"`
+	SellerId        string        `json:"seller_id,omitempty" validate:"max=28"`
+	Body            string        `json:"body,omitempty" validate:"max=128"`
+	GoodsDetail     []GoodsDetail `json:"goods_detail,omitempty"`
+	ExtendParams    *ExtendParams `json:"extend_params,omitempty"`
+	StoreId         string        `json:"store_id,omitempty" validate:"max=32"`
+	TerminalId      string        `json:"terminal_id,omitempty" validate:"max=32"`
+	SettleInfo      *SettleInfo   `json:"settle_info,omitempty"`
+}
+
+// Validate 校验预授权转支付请求参数
+func (req *FundAuthTradePayRequest) Validate() error {
+	return validateFields(req)
+}
+
+// fundAuthVoucherCreateResponse 资金授权发码响应参数
+type fundAuthVoucherCreateResponse struct {
+	PayResponse
+	Response FundAuthVoucherCreateResponseData `json:"alipay_fund_auth_order_voucher_create_response"`
+}
+
+// FundAuthVoucherCreateResponseData 资金授权发码响应参数数据
+type FundAuthVoucherCreateResponseData struct {
+	PayResponseData
+	OutOrderNo   string `json:"out_order_no"`
+	OutRequestNo string `json:"out_request_no"`
+	CodeType     string `json:"code_type"`
+	CodeValue    string `json:"code_value"`
+	CodeUrl      string `json:"code_url"`
+}
+
+// fundAuthOperationDetailQueryResponse 资金授权操作查询响应参数
+type fundAuthOperationDetailQueryResponse struct {
+	PayResponse
+	Response FundAuthOperationDetailQueryResponseData `json:"alipay_fund_auth_operation_detail_query_response"`
+}
+
+// FundAuthOperationDetailQueryResponseData 资金授权操作查询响应参数数据
+type FundAuthOperationDetailQueryResponseData struct {
+	PayResponseData
+	AuthNo                  string                  `json:"auth_no"`
+	OutOrderNo              string                  `json:"out_order_no"`
+	OrderStatus             FundAuthOrderStatus     `json:"order_status"`
+	TotalFreezeAmount       string                  `json:"total_freeze_amount"`
+	RestAmount              string                  `json:"rest_amount"`
+	TotalPayAmount          string                  `json:"total_pay_amount"`
+	OrderTitle              string                  `json:"order_title"`
+	PayerLogonId            string                  `json:"payer_logon_id"`
+	PayerUserId             string                  `json:"payer_user_id"`
+	PayerOpenId             string                  `json:"payer_open_id"`
+	ExtraParam              string                  `json:"extra_param"`
+	OperationId             string                  `json:"operation_id"`
+	OutRequestNo            string                  `json:"out_request_no"`
+	Amount                  string                  `json:"amount"`
+	OperationType           string                  `json:"operation_type"` // FREEZE 冻结，UNFREEZE 解冻，PAY 转支付
+	Status                  FundAuthOperationStatus `json:"status"`
+	Remark                  string                  `json:"remark"`
+	GmtCreate               string                  `json:"gmt_create"`
+	GmtTrans                string                  `json:"gmt_trans"`
+	PreAuthType             string                  `json:"pre_auth_type"`
+	TransCurrency           string                  `json:"trans_currency"`
+	TotalFreezeCreditAmount string                  `json:"total_freeze_credit_amount"`
+	TotalFreezeFundAmount   string                  `json:"total_freeze_fund_amount"`
+	TotalPayCreditAmount    string                  `json:"total_pay_credit_amount"`
+	TotalPayFundAmount      string                  `json:"total_pay_fund_amount"`
+	RestCreditAmount        string                  `json:"rest_credit_amount"`
+	RestFundAmount          string                  `json:"rest_fund_amount"`
+	CreditAmount            string                  `json:"credit_amount"`
+	FundAmount              string                  `json:"fund_amount"`
+}
+
+// fundAuthUnfreezeResponse 资金授权解冻响应参数
+type fundAuthUnfreezeResponse struct {
+	PayResponse
+	Response FundAuthUnfreezeResponseData `json:"alipay_fund_auth_order_unfreeze_response"`
+}
+
+// FundAuthUnfreezeResponseData 资金授权解冻响应参数数据
+type FundAuthUnfreezeResponseData struct {
+	PayResponseData
+	AuthNo       string                  `json:"auth_no"`
+	OutOrderNo   string                  `json:"out_order_no"`
+	OperationId  string                  `json:"operation_id"`
+	OutRequestNo string                  `json:"out_request_no"`
+	Amount       string                  `json:"amount"`
+	Status       FundAuthOperationStatus `json:"status"`
+	GmtTrans     string                  `json:"gmt_trans"`
+	CreditAmount string                  `json:"credit_amount"`
+	FundAmount   string                  `json:"fund_amount"`
+}
+
+// FundAuthNotification 资金授权冻结异步通知参数
+type FundAuthNotification struct {
+	NotifyTime              string                  `json:"notify_time"`
+	NotifyType              string                  `json:"notify_type"` // fund_auth_freeze
+	NotifyId                string                  `json:"notify_id"`
+	AppId                   string                  `json:"app_id"`
+	AuthAppId               string                  `json:"auth_app_id"`
+	Charset                 string                  `json:"charset"`
+	Version                 string                  `json:"version"`
+	AuthNo                  string                  `json:"auth_no"`
+	OutOrderNo              string                  `json:"out_order_no"`
+	OperationId             string                  `json:"operation_id"`
+	OutRequestNo            string                  `json:"out_request_no"`
+	OperationType           string                  `json:"operation_type"`
+	Amount                  string                  `json:"amount"`
+	Status                  FundAuthOperationStatus `json:"status"`
+	GmtCreate               string                  `json:"gmt_create"`
+	GmtTrans                string                  `json:"gmt_trans"`
+	PayerLogonId            string                  `json:"payer_logon_id"`
+	PayerUserId             string                  `json:"payer_user_id"`
+	PayerOpenId             string                  `json:"payer_open_id"`
+	PayeeLogonId            string                  `json:"payee_logon_id"`
+	PayeeUserId             string                  `json:"payee_user_id"`
+	TotalFreezeAmount       string                  `json:"total_freeze_amount"`
+	TotalUnfreezeAmount     string                  `json:"total_unfreeze_amount"`
+	TotalPayAmount          string                  `json:"total_pay_amount"`
+	RestAmount              string                  `json:"rest_amount"`
+	CreditAmount            string                  `json:"credit_amount"`
+	FundAmount              string                  `json:"fund_amount"`
+	TotalFreezeCreditAmount string                  `json:"total_freeze_credit_amount"`
+	TotalFreezeFundAmount   string                  `json:"total_freeze_fund_amount"`
+	PreAuthType             string                  `json:"pre_auth_type"`
+	TransCurrency           string                  `json:"trans_currency"`
+}
+
+// FundAuthAppFreeze 线上资金授权冻结，生成客户端调起支付宝SDK所需的订单信息字符串
+// 该接口仅在本地完成签名，不请求支付宝网关，冻结结果通过异步通知获取
+// @params bizContent interface{} 业务数据，*FundAuthFreezeRequest 或 map[string]interface{}
+func (pay *AliPay) FundAuthAppFreeze(bizContent interface{}) (orderStr string, err error) {
+	m, err := bizMap(bizContent)
+	if err != nil {
+		return "", err
+	}
+
+	return pay.sdkOrderStr("alipay.fund.auth.order.app.freeze", withBizDefault(m, "product_code", fundAuthProductCode))
+}
+
+// FundAuthVoucherCreate 资金授权发码，生成用户扫码完成冻结的二维码
+// @params bizContent interface{} 业务数据，*FundAuthFreezeRequest 或 map[string]interface{}
+func (pay *AliPay) FundAuthVoucherCreate(ctx context.Context, bizContent interface{}) (*FundAuthVoucherCreateResponseData, error) {
+	m, err := bizMap(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	biz, err := json.Marshal(withBizDefault(m, "product_code", fundAuthOfflineCode))
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.fund.auth.order.voucher.create", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result fundAuthVoucherCreateResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
+
+// FundAuthOperationDetailQuery 资金授权操作查询
+// @params authNo string 支付宝授权单号，与outOrderNo二选一
+// @params outOrderNo string 商户授权单号，与authNo二选一
+// @params operationId string 支付宝授权资金操作流水号，与outRequestNo二选一
+// @params outRequestNo string 商户授权资金操作流水号，与operationId二选一
+func (pay *AliPay) FundAuthOperationDetailQuery(ctx context.Context, authNo, outOrderNo, operationId, outRequestNo string) (*FundAuthOperationDetailQueryResponseData, error) {
+	if authNo == "" && outOrderNo == "" {
+		return nil, errors.New("auth_no 和 out_order_no 不能同时为空")
+	}
+	if operationId == "" && outRequestNo == "" {
+		return nil, errors.New("operation_id 和 out_request_no 不能同时为空")
+	}
+
+	bizContent := map[string]interface{}{}
+	if authNo != "" {
+		bizContent["auth_no"] = authNo
+	}
+	if outOrderNo != "" {
+		bizContent["out_order_no"] = outOrderNo
+	}
+	if operationId != "" {
+		bizContent["operation_id"] = operationId
+	}
+	if outRequestNo != "" {
+		bizContent["out_request_no"] = outRequestNo
+	}
+
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.fund.auth.operation.detail.query", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result fundAuthOperationDetailQueryResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
+
+// FundAuthUnfreeze 资金授权解冻，将冻结资金解冻返还给用户
+// @params bizContent interface{} 业务数据，*FundAuthUnfreezeRequest 或 map[string]interface{}
+func (pay *AliPay) FundAuthUnfreeze(ctx context.Context, bizContent interface{}) (*FundAuthUnfreezeResponseData, error) {
+	biz, err := marshalBiz(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.fund.auth.order.unfreeze", biz)
+	if err != nil {
+		return nil, err
+	}
+
+	var result fundAuthUnfreezeResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
+
+// FundAuthPay 预授权转支付，将冻结资金转为交易支付
+// 若请求失败或支付接口返回处理结果未知的错误，会以商户订单号查询交易结果，已支付成功时视为本次支付成功
+// @params bizContent interface{} 业务数据，*FundAuthTradePayRequest 或 map[string]interface{}
+func (pay *AliPay) FundAuthPay(ctx context.Context, bizContent interface{}) (*TradePayResponseData, error) {
+	m, err := bizMap(bizContent)
+	if err != nil {
+		return nil, err
+	}
+	if authNo, _ := m["auth_no"].(string); authNo == "" {
+		return nil, errors.New("auth_no 不能为空")
+	}
+
+	// auth_confirm_mode 由调用方指定，未指定时支付宝不解冻剩余冻结金额，可多次转支付
+	return pay.tradePay(ctx, withBizDefault(m, "product_code", fundAuthPayProductCode))
+}
+
+// FundAuthHandler 处理资金授权冻结异步通知
+// 验证签名及应用ID后调用业务回调，并向支付宝响应 success 或 failure
+// @params writer http.ResponseWriter 通知响应
+// @params request *http.Request 通知请求
+// @params bizCallback func(notification *FundAuthNotification) error 业务回调
+func (notify *notify) FundAuthHandler(
+	writer http.ResponseWriter,
+	request *http.Request,
+	bizCallback func(notification *FundAuthNotification) error,
+) error {
+	notification := new(FundAuthNotification)
+	return notify.handle(writer, request, notification, func(url.Values) error {
+		return errors.Wrap(bizCallback(notification), "资金授权业务处理失败")
+	})
+}
@@ -0,0 +1,71 @@
+package alipay
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/dysodeng/payment/support/crypto/rsa"
+	"github.com/pkg/errors"
+)
+
+// loadKeys 读取并解析应用私钥及支付宝公钥，解析结果缓存供签名及验签使用
+func (pay *AliPay) loadKeys() error {
+	c := pay.config
+	if c.privateKeyPath != "" {
+		if err := readFile(c.privateKeyPath, &c.privateKey); err != nil {
+			return errors.Wrap(err, "读取应用私钥文件失败")
+		}
+	}
+	if c.privateKeyReader != nil {
+		if err := readAll(c.privateKeyReader, &c.privateKey); err != nil {
+			return errors.Wrap(err, "读取应用私钥失败")
+		}
+		c.privateKeyReader = nil
+	}
+	if c.alipayPublicKeyPath != "" {
+		if err := readFile(c.alipayPublicKeyPath, &c.alipayPublicKey); err != nil {
+			return errors.Wrap(err, "读取支付宝公钥文件失败")
+		}
+	}
+	if c.alipayPublicKeyReader != nil {
+		if err := readAll(c.alipayPublicKeyReader, &c.alipayPublicKey); err != nil {
+			return errors.Wrap(err, "读取支付宝公钥失败")
+		}
+		c.alipayPublicKeyReader = nil
+	}
+
+	if c.privateKey == "" {
+		return errors.New("应用私钥不能为空")
+	}
+	privateKey, err := rsa.ParsePrivateKey(c.privateKey)
+	if err != nil {
+		return errors.Wrap(err, "应用私钥错误")
+	}
+	pay.privateKey = privateKey
+
+	// 公钥证书模式下使用支付宝公钥证书中的公钥验签
+	if pay.isCertMode() {
+		return nil
+	}
+
+	if c.alipayPublicKey == "" {
+		return errors.New("支付宝公钥不能为空，公钥证书模式请使用 WithCert 或 WithCertFile")
+	}
+	alipayPublicKey, err := rsa.ParsePublicKey(c.alipayPublicKey)
+	if err != nil {
+		return errors.Wrap(err, "支付宝公钥错误")
+	}
+	pay.alipayPublicKey = alipayPublicKey
+
+	return nil
+}
+
+// readAll 读取 io.Reader 全部内容
+func readAll(reader io.Reader, content *string) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	*content = string(data)
+	return nil
+}
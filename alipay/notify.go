@@ -0,0 +1,161 @@
+package alipay
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	notifySuccess = "success" // 通知处理成功响应内容
+	notifyFailure = "failure" // 通知处理失败响应内容，支付宝将重新发送通知
+)
+
+// notify 异步通知
+type notify struct {
+	pay *AliPay
+}
+
+// TradeNotification 交易异步通知参数
+type TradeNotification struct {
+	NotifyTime        string           `json:"notify_time"`
+	NotifyType        string           `json:"notify_type"`
+	NotifyId          string           `json:"notify_id"`
+	AppId             string           `json:"app_id"`
+	AuthAppId         string           `json:"auth_app_id"`
+	Charset           string           `json:"charset"`
+	Version           string           `json:"version"`
+	TradeNo           string           `json:"trade_no"`
+	OutTradeNo        string           `json:"out_trade_no"`
+	OutBizNo          string           `json:"out_biz_no"`
+	BuyerId           string           `json:"buyer_id"`
+	BuyerOpenId       string           `json:"buyer_open_id"`
+	BuyerLogonId      string           `json:"buyer_logon_id"`
+	SellerId          string           `json:"seller_id"`
+	SellerEmail       string           `json:"seller_email"`
+	TradeStatus       TradeStatus      `json:"trade_status"`
+	TotalAmount       string           `json:"total_amount"`
+	ReceiptAmount     string           `json:"receipt_amount"`
+	InvoiceAmount     string           `json:"invoice_amount"`
+	BuyerPayAmount    string           `json:"buyer_pay_amount"`
+	PointAmount       string           `json:"point_amount"`
+	RefundFee         string           `json:"refund_fee"`
+	Subject           string           `json:"subject"`
+	Body              string           `json:"body"`
+	GmtCreate         string           `json:"gmt_create"`
+	GmtPayment        string           `json:"gmt_payment"`
+	GmtRefund         string           `json:"gmt_refund"`
+	GmtClose          string           `json:"gmt_close"`
+	PassbackParams    string           `json:"passback_params"`
+	VoucherDetailList string           `json:"voucher_detail_list"`
+	FundBillList      []NotifyFundBill `json:"-"`
+}
+
+// NotifyFundBill 异步通知中的支付资金渠道，字段名为驼峰格式
+type NotifyFundBill struct {
+	FundChannel string `json:"fundChannel"`
+	Amount      string `json:"amount"`
+	RealAmount  string `json:"realAmount"`
+}
+
+// Handler 处理交易异步通知
+// 验证签名及应用ID后调用业务回调，并向支付宝响应 success 或 failure
+// @params writer http.ResponseWriter 通知响应
+// @params request *http.Request 通知请求
+// @params bizCallback func(notification *TradeNotification) error 业务回调
+func (notify *notify) Handler(
+	writer http.ResponseWriter,
+	request *http.Request,
+	bizCallback func(notification *TradeNotification) error,
+) error {
+	notification := new(TradeNotification)
+	return notify.handle(writer, request, notification, func(values url.Values) error {
+		if fundBillList := values.Get("fund_bill_list"); fundBillList != "" {
+			if err := json.Unmarshal([]byte(fundBillList), &notification.FundBillList); err != nil {
+				return errors.Wrap(err, "支付宝通知资金明细解析失败")
+			}
+		}
+		return errors.Wrap(bizCallback(notification), "支付业务处理失败")
+	})
+}
+
+// handle 解析并验证异步通知，调用业务回调后向支付宝响应 success 或 failure
+// @params notification interface{} 通知参数结构体指针
+// @params bizCallback func(values url.Values) error 业务回调，values 为已验签的通知参数
+func (notify *notify) handle(
+	writer http.ResponseWriter,
+	request *http.Request,
+	notification interface{},
+	bizCallback func(values url.Values) error,
+) error {
+	values, err := notify.parse(request, notification)
+	if err != nil {
+		log.Printf("%+v", err)
+		notify.response(writer, notifyFailure)
+		return errors.Wrap(err, "支付宝通知验签失败")
+	}
+
+	if err = bizCallback(values); err != nil {
+		notify.response(writer, notifyFailure)
+		return err
+	}
+
+	notify.response(writer, notifySuccess)
+	return nil
+}
+
+// parse 解析并验证异步通知，将通知参数解码至 notification
+// @params request *http.Request 通知请求
+// @params notification interface{} 通知参数结构体指针
+func (notify *notify) parse(request *http.Request, notification interface{}) (url.Values, error) {
+	if err := request.ParseForm(); err != nil {
+		return nil, errors.Wrap(err, "解析通知参数失败")
+	}
+
+	// 仅使用请求体参数，notify_url 自带的查询参数不参与验签
+	values := request.PostForm
+	// 待验签内容为通知原始编码，验签通过后再转换为UTF-8
+	ok, err := notify.pay.CheckCallbackSign(request.Context(), values)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("通知签名错误")
+	}
+
+	charset := values.Get("charset")
+	if charset == "" {
+		charset = contentTypeCharset(request.Header.Get("Content-Type"))
+	}
+	if values, err = valuesToUtf8(values, charset); err != nil {
+		return nil, err
+	}
+
+	if appId := values.Get("app_id"); appId != notify.pay.config.appId {
+		return nil, errors.Errorf("通知应用ID不匹配: %s", appId)
+	}
+
+	m := make(map[string]string, len(values))
+	for key := range values {
+		m[key] = values.Get(key)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, notification); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// response 响应支付宝通知
+func (notify *notify) response(writer http.ResponseWriter, body string) {
+	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	writer.WriteHeader(http.StatusOK)
+	_, _ = writer.Write([]byte(body))
+}
@@ -0,0 +1,76 @@
+package alipay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	pay, key := newTestAliPay(t)
+
+	params := map[string]string{
+		"app_id":         pay.config.appId,
+		"notify_id":      "2024010100222000000000000000000000",
+		"out_trade_no":   "20240101000001",
+		"total_amount":   "9.90",
+		"trade_status":   string(TradeStatusSuccess),
+		"fund_bill_list": `[{"amount":"9.90","fundChannel":"ALIPAYACCOUNT"}]`,
+	}
+	form := url.Values{}
+	for key, value := range params {
+		form.Set(key, value)
+	}
+	form.Set("sign_type", "RSA2")
+	form.Set("sign", signTest(t, key, pay.signString(params)))
+
+	tests := []struct {
+		name   string
+		target string
+		form   url.Values
+		want   string
+	}{
+		{name: "signed", target: "/notify", form: form, want: notifySuccess},
+		{name: "notify url with query string", target: "/notify?shop=1&out_trade_no=OTHER", form: form, want: notifySuccess},
+		{name: "tampered", target: "/notify", form: func() url.Values {
+			tampered := url.Values{}
+			for key, value := range form {
+				tampered[key] = value
+			}
+			tampered.Set("total_amount", "0.01")
+			return tampered
+		}(), want: notifyFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+			recorder := httptest.NewRecorder()
+
+			var notification *TradeNotification
+			err := pay.Notify().Handler(recorder, request, func(n *TradeNotification) error {
+				notification = n
+				return nil
+			})
+
+			if body := recorder.Body.String(); body != tt.want {
+				t.Fatalf("Handler() response = %s, want %s, error = %v", body, tt.want, err)
+			}
+			if tt.want == notifyFailure {
+				if err == nil || notification != nil {
+					t.Fatal("Handler() want error without callback")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Handler() error = %v", err)
+			}
+			if notification.OutTradeNo != "20240101000001" || len(notification.FundBillList) != 1 {
+				t.Fatalf("Handler() notification = %+v", notification)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// oauthTokenResponse 换取授权访问令牌响应参数
+type oauthTokenResponse struct {
+	PayResponse
+	Response OauthTokenResponseData `json:"alipay_system_oauth_token_response"`
+}
+
+// OauthTokenResponseData 换取授权访问令牌响应参数数据
+type OauthTokenResponseData struct {
+	PayResponseData
+	UserId       string      `json:"user_id"`
+	OpenId       string      `json:"open_id"`
+	AccessToken  string      `json:"access_token"`
+	ExpiresIn    json.Number `json:"expires_in"`    // 访问令牌有效期，单位秒
+	RefreshToken string      `json:"refresh_token"` // 刷新令牌
+	ReExpiresIn  json.Number `json:"re_expires_in"` // 刷新令牌有效期，单位秒
+	AuthStart    string      `json:"auth_start"`
+}
+
+// userInfoShareResponse 支付宝会员授权信息查询响应参数
+type userInfoShareResponse struct {
+	PayResponse
+	Response UserInfoShareResponseData `json:"alipay_user_info_share_response"`
+}
+
+// UserInfoShareResponseData 支付宝会员授权信息查询响应参数数据
+type UserInfoShareResponseData struct {
+	PayResponseData
+	UserId   string `json:"user_id"`
+	OpenId   string `json:"open_id"`
+	Avatar   string `json:"avatar"`
+	NickName string `json:"nick_name"`
+	Province string `json:"province"`
+	City     string `json:"city"`
+	Gender   string `json:"gender"` // F 女性，M 男性
+}
+
+// OauthToken 使用用户授权码换取授权访问令牌，获取买家 user_id/open_id
+// @params code string 用户授权码 auth_code
+func (pay *AliPay) OauthToken(ctx context.Context, code string) (*OauthTokenResponseData, error) {
+	if code == "" {
+		return nil, errors.New("code 不能为空")
+	}
+	return pay.oauthToken(ctx, map[string]string{
+		"grant_type": "authorization_code",
+		"code":       code,
+	})
+}
+
+// RefreshOauthToken 使用刷新令牌刷新授权访问令牌
+// @params refreshToken string 刷新令牌
+func (pay *AliPay) RefreshOauthToken(ctx context.Context, refreshToken string) (*OauthTokenResponseData, error) {
+	if refreshToken == "" {
+		return nil, errors.New("refresh_token 不能为空")
+	}
+	return pay.oauthToken(ctx, map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": refreshToken,
+	})
+}
+
+// oauthToken 换取或刷新授权访问令牌，grant_type、code、refresh_token 均为公共参数
+func (pay *AliPay) oauthToken(ctx context.Context, params map[string]string) (*OauthTokenResponseData, error) {
+	response, err := pay.callWithParams(ctx, "alipay.system.oauth.token", "", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result oauthTokenResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	// 该接口成功响应不返回 code，失败时通过 error_response 节点返回
+	if result.Response.Code != "" {
+		if err = result.Response.err(response); err != nil {
+			return nil, err
+		}
+	}
+	if result.Response.AccessToken == "" {
+		return nil, errors.New("响应中未包含授权访问令牌")
+	}
+
+	return &result.Response, nil
+}
+
+// UserInfoShare 支付宝会员授权信息查询
+// @params authToken string 用户授权访问令牌 access_token
+func (pay *AliPay) UserInfoShare(ctx context.Context, authToken string) (*UserInfoShareResponseData, error) {
+	if authToken == "" {
+		return nil, errors.New("auth_token 不能为空")
+	}
+
+	response, err := pay.callWithParams(ctx, "alipay.user.info.share", "", map[string]string{
+		"auth_token": authToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result userInfoShareResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
@@ -1,8 +1,19 @@
 package alipay
 
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
 const (
 	prodGateway = "https://openapi.alipay.com/gateway.do"    // 线上环境
 	devGateway  = "https://openapi.alipaydev.com/gateway.do" // 沙箱环境
+
+	successCode     = "10000" // 接口调用成功
+	systemErrorCode = "20000" // 服务不可用，业务处理结果未知
+
+	defaultHttpTimeout = 30 * time.Second // 默认接口请求超时时间
 )
 
 // config 支付宝配置
@@ -14,6 +25,21 @@ type config struct {
 	notifyUrl       string // 支付结果异步通知地址
 	returnUrl       string // 支付完成跳转地址
 	appAuthToken    string // app auth token
+	encryptKey      string // 接口内容加密密钥（AES，base64编码）
+	charset         string // 请求编码，utf-8 或 gbk
+	httpClient      *http.Client
+
+	appCert            string // 应用公钥证书内容
+	alipayCert         string // 支付宝公钥证书内容
+	alipayRootCert     string // 支付宝根证书内容
+	appCertPath        string // 应用公钥证书文件路径
+	alipayCertPath     string // 支付宝公钥证书文件路径
+	alipayRootCertPath string // 支付宝根证书文件路径
+
+	privateKeyPath        string    // 应用私钥文件路径
+	privateKeyReader      io.Reader // 应用私钥读取源
+	alipayPublicKeyPath   string    // 支付宝公钥文件路径
+	alipayPublicKeyReader io.Reader // 支付宝公钥读取源
 }
 
 type Option func(*config)
@@ -39,9 +65,102 @@ func WithReturnUrl(returnUrl string) Option {
 	}
 }
 
+// WithHttpClient 设置接口请求使用的 http.Client，可用于设置代理、超时等
+func WithHttpClient(client *http.Client) Option {
+	return func(c *config) {
+		if client != nil {
+			c.httpClient = client
+		}
+	}
+}
+
+// WithTransport 设置接口请求使用的 http.RoundTripper
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *config) {
+		c.httpClient = &http.Client{
+			Transport: transport,
+			Timeout:   defaultHttpTimeout,
+		}
+	}
+}
+
 // WithAppAuthToken setting app auth token
 func WithAppAuthToken(appAuthToken string) Option {
 	return func(c *config) {
 		c.appAuthToken = appAuthToken
 	}
 }
+
+// WithEncryptKey 设置接口内容加密密钥，启用后请求业务数据及响应内容使用AES加密
+// @param encryptKey string 开放平台配置的AES密钥，base64编码
+func WithEncryptKey(encryptKey string) Option {
+	return func(c *config) {
+		c.encryptKey = encryptKey
+	}
+}
+
+// WithPrivateKeyFile 从文件读取应用私钥，覆盖 New 传入的应用私钥
+// @param path string 私钥文件路径，PEM格式（PKCS#1 或 PKCS#8）或base64编码
+func WithPrivateKeyFile(path string) Option {
+	return func(c *config) {
+		c.privateKeyPath = path
+	}
+}
+
+// WithPrivateKeyReader 从 io.Reader 读取应用私钥，覆盖 New 传入的应用私钥
+// @param reader io.Reader 私钥读取源，PEM格式（PKCS#1 或 PKCS#8）或base64编码
+func WithPrivateKeyReader(reader io.Reader) Option {
+	return func(c *config) {
+		c.privateKeyReader = reader
+	}
+}
+
+// WithAlipayPublicKeyFile 从文件读取支付宝公钥，覆盖 New 传入的支付宝公钥
+// @param path string 公钥文件路径，PEM格式或base64编码
+func WithAlipayPublicKeyFile(path string) Option {
+	return func(c *config) {
+		c.alipayPublicKeyPath = path
+	}
+}
+
+// WithAlipayPublicKeyReader 从 io.Reader 读取支付宝公钥，覆盖 New 传入的支付宝公钥
+// @param reader io.Reader 公钥读取源，PEM格式或base64编码
+func WithAlipayPublicKeyReader(reader io.Reader) Option {
+	return func(c *config) {
+		c.alipayPublicKeyReader = reader
+	}
+}
+
+// WithCharset 设置请求编码，默认 utf-8
+// 设置为 gbk 时请求参数以GBK编码签名及提交，响应内容按实际编码转换为UTF-8
+// gbk 编码时不支持 PagePayForm、WapPayForm 等HTML表单，调用将返回错误，请改用跳转地址
+// @param charset string 请求编码，CharsetUtf8 或 CharsetGbk
+func WithCharset(charset string) Option {
+	return func(c *config) {
+		c.charset = charset
+	}
+}
+
+// WithCert 启用公钥证书模式，设置证书内容
+// @param appCert string 应用公钥证书 appCertPublicKey.crt
+// @param alipayCert string 支付宝公钥证书 alipayCertPublicKey_RSA2.crt
+// @param alipayRootCert string 支付宝根证书 alipayRootCert.crt
+func WithCert(appCert, alipayCert, alipayRootCert string) Option {
+	return func(c *config) {
+		c.appCert = appCert
+		c.alipayCert = alipayCert
+		c.alipayRootCert = alipayRootCert
+	}
+}
+
+// WithCertFile 启用公钥证书模式，设置证书文件路径
+// @param appCertPath string 应用公钥证书文件路径
+// @param alipayCertPath string 支付宝公钥证书文件路径
+// @param alipayRootCertPath string 支付宝根证书文件路径
+func WithCertFile(appCertPath, alipayCertPath, alipayRootCertPath string) Option {
+	return func(c *config) {
+		c.appCertPath = appCertPath
+		c.alipayCertPath = alipayCertPath
+		c.alipayRootCertPath = alipayRootCertPath
+	}
+}
@@ -0,0 +1,52 @@
+package alipay
+
+// pagePayProductCode 电脑网站支付产品码
+const pagePayProductCode = "FAST_INSTANT_TRADE_PAY"
+
+// TradePagePayRequest 电脑网站支付请求参数
+type TradePagePayRequest struct {
+	OutTradeNo      string          `json:"out_trade_no" validate:"required,max=64"`
+	TotalAmount     string          `json:"total_amount" validate:"required,amount"`
+	Subject         string          `json:"subject" validate:"required,max=256"`
+	ProductCode     string          `json:"product_code,omitempty" validate:"max=64"`
+	QrPayMode       string          `json:"qr_pay_mode,omitempty" validate:"max=2"`
+	QrcodeWidth     int             `json:"qrcode_width,omitempty"`
+	Body            string          `json:"body,omitempty" validate:"max=128"`
+	GoodsDetail     []GoodsDetail   `json:"goods_detail,omitempty"`
+	TimeExpire      string          `json:"time_expire,omitempty" validate:"max=32"`
+	TimeoutExpress  string          `json:"timeout_express,omitempty" validate:"max=6"`
+	ExtendParams    *ExtendParams   `json:"extend_params,omitempty"`
+	BusinessParams  *BusinessParams `json:"business_params,omitempty"`
+	PassbackParams  string          `json:"passback_params,omitempty" validate:"max=512"`
+	IntegrationType string          `json:"integration_type,omitempty" validate:"max=16"`
+	RequestFromUrl  string          `json:"request_from_url,omitempty" validate:"max=256"`
+	StoreId         string          `json:"store_id,omitempty" validate:"max=32"`
+	MerchantOrderNo string          `json:"merchant_order_no,omitempty" validate:"max=32"`
+	SettleInfo      *SettleInfo     `json:"settle_info,omitempty"`
+}
+
+// Validate 校验电脑网站支付请求参数
+func (req *TradePagePayRequest) Validate() error {
+	return validateFields(req)
+}
+
+// PagePay 电脑网站支付，生成跳转至支付宝收银台的地址
+// 支付完成后跳转至 WithReturnUrl 设置的地址，支付结果异步通知至 WithNotifyUrl 设置的地址
+// @params bizContent interface{} 业务数据，*TradePagePayRequest 或 map[string]interface{}
+func (pay *AliPay) PagePay(bizContent interface{}) (payUrl string, err error) {
+	biz, err := bizMap(bizContent)
+	if err != nil {
+		return "", err
+	}
+	return pay.pageUrl("alipay.trade.page.pay", withBizDefault(biz, "product_code", pagePayProductCode))
+}
+
+// PagePayForm 电脑网站支付，生成自动提交至支付宝收银台的HTML表单
+// @params bizContent interface{} 业务数据，*TradePagePayRequest 或 map[string]interface{}
+func (pay *AliPay) PagePayForm(bizContent interface{}) (form string, err error) {
+	biz, err := bizMap(bizContent)
+	if err != nil {
+		return "", err
+	}
+	return pay.pageForm("alipay.trade.page.pay", withBizDefault(biz, "product_code", pagePayProductCode))
+}
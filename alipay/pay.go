@@ -0,0 +1,257 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	waitBuyerPayCode = "10003" // 业务处理中，等待用户输入支付密码
+
+	defaultPayTimeout      = 30 * time.Second // 默认等待用户付款时间
+	defaultPayPollInterval = 5 * time.Second  // 默认交易查询间隔
+	payCancelTimeout       = 10 * time.Second // ctx 取消后撤销交易的超时时间
+	payQueryTimeout        = 10 * time.Second // ctx 取消后确认交易结果的超时时间
+)
+
+var (
+	ErrTradePayTimeout = errors.New("等待用户付款超时，交易已撤销")
+	ErrTradeClosed     = errors.New("交易已关闭")
+)
+
+type payOption struct {
+	timeout      time.Duration
+	pollInterval time.Duration
+}
+
+type PayOption func(*payOption)
+
+// WithPayTimeout 设置等待用户付款的最长时间，超时后撤销交易
+func WithPayTimeout(timeout time.Duration) PayOption {
+	return func(option *payOption) {
+		option.timeout = timeout
+	}
+}
+
+// WithPayPollInterval 设置等待用户付款时的交易查询间隔
+func WithPayPollInterval(interval time.Duration) PayOption {
+	return func(option *payOption) {
+		option.pollInterval = interval
+	}
+}
+
+// payResponse 统一收单交易支付响应参数
+type payResponse struct {
+	PayResponse
+	Response TradePayResponseData `json:"alipay_trade_pay_response"`
+}
+
+// TradePayResponseData 统一收单交易支付响应参数数据
+type TradePayResponseData struct {
+	PayResponseData
+	TradeNo        string     `json:"trade_no"`
+	OutTradeNo     string     `json:"out_trade_no"`
+	BuyerLogonId   string     `json:"buyer_logon_id"`
+	BuyerUserId    string     `json:"buyer_user_id"`
+	BuyerOpenId    string     `json:"buyer_open_id"`
+	TotalAmount    string     `json:"total_amount"`
+	ReceiptAmount  string     `json:"receipt_amount"`
+	BuyerPayAmount string     `json:"buyer_pay_amount"`
+	PointAmount    string     `json:"point_amount"`
+	InvoiceAmount  string     `json:"invoice_amount"`
+	GmtPayment     string     `json:"gmt_payment"`
+	StoreName      string     `json:"store_name"`
+	FundBillList   []FundBill `json:"fund_bill_list"`
+}
+
+// TradePayRequest 统一收单交易支付请求参数
+type TradePayRequest struct {
+	OutTradeNo     string          `json:"out_trade_no" validate:"required,max=64"`
+	TotalAmount    string          `json:"total_amount" validate:"required,amount"`
+	Subject        string          `json:"subject" validate:"required,max=256"`
+	AuthCode       string          `json:"auth_code" validate:"required,max=128"`
+	Scene          string          `json:"scene,omitempty" validate:"max=32"`
+	ProductCode    string          `json:"product_code,omitempty" validate:"max=32"`
+	SellerId       string          `json:"seller_id,omitempty" validate:"max=28"`
+	Body           string          `json:"body,omitempty" validate:"max=128"`
+	GoodsDetail    []GoodsDetail   `json:"goods_detail,omitempty"`
+	ExtendParams   *ExtendParams   `json:"extend_params,omitempty"`
+	BusinessParams *BusinessParams `json:"business_params,omitempty"`
+	OperatorId     string          `json:"operator_id,omitempty" validate:"max=28"`
+	StoreId        string          `json:"store_id,omitempty" validate:"max=32"`
+	TerminalId     string          `json:"terminal_id,omitempty" validate:"max=32"`
+	TimeoutExpress string          `json:"timeout_express,omitempty" validate:"max=6"`
+	QueryOptions   []string        `json:"query_options,omitempty"`
+	SettleInfo     *SettleInfo     `json:"settle_info,omitempty"`
+}
+
+// Validate 校验统一收单交易支付请求参数
+func (req *TradePayRequest) Validate() error {
+	return validateFields(req)
+}
+
+// Pay 统一收单交易支付（当面付-付款码支付）
+// 支付宝返回等待用户付款、处理结果未知或请求失败时，轮询交易查询接口直至支付成功、交易关闭或超时，超时后撤销交易
+// ctx 取消时同样撤销交易，撤销使用独立的超时时间
+// @params bizContent interface{} 业务数据，*TradePayRequest 或 map[string]interface{}
+func (pay *AliPay) Pay(ctx context.Context, bizContent interface{}, opts ...PayOption) (*TradePayResponseData, error) {
+	o := &payOption{
+		timeout:      defaultPayTimeout,
+		pollInterval: defaultPayPollInterval,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	m, err := bizMap(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	biz, err := json.Marshal(withBizDefault(m, "scene", "bar_code"))
+	if err != nil {
+		return nil, err
+	}
+
+	outTradeNo, _ := m["out_trade_no"].(string)
+	response, err := pay.call(ctx, "alipay.trade.pay", string(biz))
+	if err != nil {
+		// 请求失败时交易可能已创建甚至已支付，按处理结果未知处理
+		if IsRequestError(err) {
+			return pay.waitBuyerPay(ctx, outTradeNo, "", o)
+		}
+		return nil, err
+	}
+
+	var result payResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	switch result.Response.Code {
+	case successCode:
+		return &result.Response, nil
+	case waitBuyerPayCode, systemErrorCode:
+		return pay.waitBuyerPay(ctx, outTradeNo, result.Response.TradeNo, o)
+	default:
+		return nil, result.Response.err(response)
+	}
+}
+
+// waitBuyerPay 轮询交易状态直至支付成功、交易关闭或超时
+// @params outTradeNo string 商户订单号
+// @params tradeNo string 支付宝交易号
+func (pay *AliPay) waitBuyerPay(ctx context.Context, outTradeNo, tradeNo string, o *payOption) (*TradePayResponseData, error) {
+	deadline := time.Now().Add(o.timeout)
+	for time.Now().Before(deadline) {
+		if err := sleep(ctx, o.pollInterval); err != nil {
+			// ctx 已取消，使用独立的超时时间撤销交易，避免交易处于未知状态
+			cancelCtx, cancel := context.WithTimeout(context.Background(), payCancelTimeout)
+			_, cancelErr := pay.Cancel(cancelCtx, outTradeNo, tradeNo)
+			cancel()
+			if cancelErr != nil {
+				return nil, errors.Wrapf(err, "等待用户付款中断，撤销交易失败: %v", cancelErr)
+			}
+			return nil, errors.Wrap(err, "等待用户付款中断，交易已撤销")
+		}
+
+		trade, err := pay.Query(ctx, outTradeNo, tradeNo)
+		if err != nil {
+			// 交易可能尚未创建或查询失败，继续等待
+			continue
+		}
+
+		switch trade.TradeStatus {
+		case TradeStatusSuccess, TradeStatusFinished:
+			return tradePayResponseData(trade), nil
+		case TradeStatusClosed:
+			return nil, ErrTradeClosed
+		}
+	}
+
+	if _, err := pay.Cancel(ctx, outTradeNo, tradeNo); err != nil {
+		return nil, errors.Wrap(err, "等待用户付款超时，撤销交易失败")
+	}
+
+	return nil, ErrTradePayTimeout
+}
+
+// tradePay 无需等待用户付款的统一收单交易支付，如预授权转支付、协议代扣
+// 若请求失败或支付接口返回处理结果未知的错误，会以商户订单号查询交易结果，已支付成功时视为本次支付成功
+// @params bizContent map[string]interface{} 业务数据
+func (pay *AliPay) tradePay(ctx context.Context, bizContent map[string]interface{}) (*TradePayResponseData, error) {
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := pay.tradePayOnce(ctx, string(biz))
+	if err == nil {
+		return data, nil
+	}
+
+	outTradeNo, _ := bizContent["out_trade_no"].(string)
+	if outTradeNo == "" || !isResultUnknown(err) {
+		return nil, err
+	}
+
+	// ctx 已取消或超时时，使用独立的超时时间确认交易结果
+	queryCtx := ctx
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		queryCtx, cancel = context.WithTimeout(context.Background(), payQueryTimeout)
+		defer cancel()
+	}
+
+	trade, queryErr := pay.Query(queryCtx, outTradeNo, "")
+	if queryErr != nil || (trade.TradeStatus != TradeStatusSuccess && trade.TradeStatus != TradeStatusFinished) {
+		return nil, err
+	}
+
+	return tradePayResponseData(trade), nil
+}
+
+// tradePayOnce 调用一次统一收单交易支付接口
+// @params bizContent string 业务数据
+func (pay *AliPay) tradePayOnce(ctx context.Context, bizContent string) (*TradePayResponseData, error) {
+	response, err := pay.call(ctx, "alipay.trade.pay", bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	var result payResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
+
+// tradePayResponseData 将交易查询结果转换为交易支付响应数据
+func tradePayResponseData(trade *QueryResponseData) *TradePayResponseData {
+	return &TradePayResponseData{
+		PayResponseData: trade.PayResponseData,
+		TradeNo:         trade.TradeNo,
+		OutTradeNo:      trade.OutTradeNo,
+		BuyerLogonId:    trade.BuyerLogonId,
+		BuyerUserId:     trade.BuyerUserId,
+		BuyerOpenId:     trade.BuyerOpenId,
+		TotalAmount:     trade.TotalAmount,
+		ReceiptAmount:   trade.ReceiptAmount,
+		BuyerPayAmount:  trade.BuyerPayAmount,
+		PointAmount:     trade.PointAmount,
+		InvoiceAmount:   trade.InvoiceAmount,
+		GmtPayment:      trade.SendPayDate,
+		StoreName:       trade.StoreName,
+		FundBillList:    trade.FundBillList,
+	}
+}
@@ -1,12 +1,37 @@
 package alipay
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-
-	"github.com/pkg/errors"
 )
 
+// TradePreCreateRequest 预下单请求参数
+type TradePreCreateRequest struct {
+	OutTradeNo           string          `json:"out_trade_no" validate:"required,max=64"`
+	TotalAmount          string          `json:"total_amount" validate:"required,amount"`
+	Subject              string          `json:"subject" validate:"required,max=256"`
+	ProductCode          string          `json:"product_code,omitempty" validate:"max=64"`
+	SellerId             string          `json:"seller_id,omitempty" validate:"max=28"`
+	Body                 string          `json:"body,omitempty" validate:"max=128"`
+	GoodsDetail          []GoodsDetail   `json:"goods_detail,omitempty"`
+	ExtendParams         *ExtendParams   `json:"extend_params,omitempty"`
+	BusinessParams       *BusinessParams `json:"business_params,omitempty"`
+	DiscountableAmount   string          `json:"discountable_amount,omitempty" validate:"amount"`
+	UndiscountableAmount string          `json:"undiscountable_amount,omitempty" validate:"amount"`
+	StoreId              string          `json:"store_id,omitempty" validate:"max=32"`
+	OperatorId           string          `json:"operator_id,omitempty" validate:"max=28"`
+	TerminalId           string          `json:"terminal_id,omitempty" validate:"max=32"`
+	MerchantOrderNo      string          `json:"merchant_order_no,omitempty" validate:"max=32"`
+	TimeoutExpress       string          `json:"timeout_express,omitempty" validate:"max=6"`
+	TimeExpire           string          `json:"time_expire,omitempty" validate:"max=32"`
+	SettleInfo           *SettleInfo     `json:"settle_info,omitempty"`
+}
+
+// Validate 校验预下单请求参数
+func (req *TradePreCreateRequest) Validate() error {
+	return validateFields(req)
+}
+
 // PreCreateResponse 预下单响应参数
 type preCreateResponse struct {
 	PayResponse
@@ -21,14 +46,14 @@ type preCreateResponseData struct {
 }
 
 // PreCreate 预下单接口（当面付-生成二维码）
-// @params bizContent string 业务数据
-func (pay *AliPay) PreCreate(bizContent map[string]interface{}) (outTradeNo, qrCode string, err error) {
-	biz, err := json.Marshal(bizContent)
+// @params bizContent interface{} 业务数据，*TradePreCreateRequest 或 map[string]interface{}
+func (pay *AliPay) PreCreate(ctx context.Context, bizContent interface{}) (outTradeNo, qrCode string, err error) {
+	biz, err := marshalBiz(bizContent)
 	if err != nil {
 		return "", "", err
 	}
 
-	response, err := pay.call("alipay.trade.precreate", string(biz))
+	response, err := pay.call(ctx, "alipay.trade.precreate", biz)
 	if err != nil {
 		return
 	}
@@ -39,14 +64,7 @@ func (pay *AliPay) PreCreate(bizContent map[string]interface{}) (outTradeNo, qrC
 		return
 	}
 
-	if result.Response.Code != "10000" {
-		err = errors.New(
-			fmt.Sprintf("msg: %s, code:%s, sub_code:%s, sub_msg:%s",
-				result.Response.Msg,
-				result.Response.Code,
-				result.Response.SubCode,
-				result.Response.SubMsg),
-		)
+	if err = result.Response.err(response); err != nil {
 		return
 	}
 
@@ -0,0 +1,104 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// TradeStatus 交易状态
+type TradeStatus string
+
+const (
+	TradeStatusWaitBuyerPay TradeStatus = "WAIT_BUYER_PAY" // 交易创建，等待买家付款
+	TradeStatusClosed       TradeStatus = "TRADE_CLOSED"   // 未付款交易超时关闭，或支付完成后全额退款
+	TradeStatusSuccess      TradeStatus = "TRADE_SUCCESS"  // 交易支付成功
+	TradeStatusFinished     TradeStatus = "TRADE_FINISHED" // 交易结束，不可退款
+)
+
+// FundBill 交易支付使用的资金渠道
+type FundBill struct {
+	FundChannel string `json:"fund_channel"`
+	Amount      string `json:"amount"`
+	RealAmount  string `json:"real_amount"`
+	FundType    string `json:"fund_type"`
+}
+
+// queryResponse 交易查询响应参数
+type queryResponse struct {
+	PayResponse
+	Response QueryResponseData `json:"alipay_trade_query_response"`
+}
+
+// QueryResponseData 交易查询响应参数数据
+type QueryResponseData struct {
+	PayResponseData
+	TradeNo         string      `json:"trade_no"`
+	OutTradeNo      string      `json:"out_trade_no"`
+	BuyerLogonId    string      `json:"buyer_logon_id"`
+	BuyerUserId     string      `json:"buyer_user_id"`
+	BuyerOpenId     string      `json:"buyer_open_id"`
+	BuyerUserType   string      `json:"buyer_user_type"`
+	TradeStatus     TradeStatus `json:"trade_status"`
+	TotalAmount     string      `json:"total_amount"`
+	TransCurrency   string      `json:"trans_currency"`
+	SettleCurrency  string      `json:"settle_currency"`
+	SettleAmount    string      `json:"settle_amount"`
+	PayCurrency     string      `json:"pay_currency"`
+	PayAmount       string      `json:"pay_amount"`
+	BuyerPayAmount  string      `json:"buyer_pay_amount"`
+	PointAmount     string      `json:"point_amount"`
+	InvoiceAmount   string      `json:"invoice_amount"`
+	ReceiptAmount   string      `json:"receipt_amount"`
+	DiscountAmount  string      `json:"discount_amount"`
+	MdiscountAmount string      `json:"mdiscount_amount"`
+	SendPayDate     string      `json:"send_pay_date"`
+	StoreId         string      `json:"store_id"`
+	StoreName       string      `json:"store_name"`
+	TerminalId      string      `json:"terminal_id"`
+	FundBillList    []FundBill  `json:"fund_bill_list"`
+}
+
+// Query 统一收单线下交易查询
+// @params outTradeNo string 商户订单号，与tradeNo二选一
+// @params tradeNo string 支付宝交易号，与outTradeNo二选一
+// @params queryOptions ...string 查询选项，如 fund_bill_list
+func (pay *AliPay) Query(ctx context.Context, outTradeNo, tradeNo string, queryOptions ...string) (*QueryResponseData, error) {
+	if outTradeNo == "" && tradeNo == "" {
+		return nil, errors.New("out_trade_no 和 trade_no 不能同时为空")
+	}
+
+	bizContent := map[string]interface{}{}
+	if outTradeNo != "" {
+		bizContent["out_trade_no"] = outTradeNo
+	}
+	if tradeNo != "" {
+		bizContent["trade_no"] = tradeNo
+	}
+	if len(queryOptions) > 0 {
+		bizContent["query_options"] = queryOptions
+	}
+
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.trade.query", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result queryResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
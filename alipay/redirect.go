@@ -0,0 +1,92 @@
+package alipay
+
+import (
+	"encoding/json"
+	"html"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pageUrl 生成跳转至支付宝网关的GET请求地址
+// @params method string 接口方法
+// @params bizContent map[string]interface{} 业务数据
+func (pay *AliPay) pageUrl(method string, bizContent map[string]interface{}) (string, error) {
+	params, err := pay.pageParams(method, bizContent)
+	if err != nil {
+		return "", err
+	}
+
+	values := url.Values{}
+	for key, value := range params {
+		values.Add(key, value)
+	}
+
+	return pay.gateway() + "?" + values.Encode(), nil
+}
+
+// pageForm 生成自动提交至支付宝网关的HTML表单
+// 表单以UTF-8页面输出，GBK编码的参数无法原样嵌入，gbk 编码时返回错误，请改用 pageUrl
+// @params method string 接口方法
+// @params bizContent map[string]interface{} 业务数据
+func (pay *AliPay) pageForm(method string, bizContent map[string]interface{}) (string, error) {
+	if isGbk(pay.config.charset) {
+		return "", errors.New("gbk 编码不支持生成HTML表单，请使用跳转地址")
+	}
+
+	params, err := pay.pageParams(method, bizContent)
+	if err != nil {
+		return "", err
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var form strings.Builder
+	form.WriteString(`<form id="alipaysubmit" name="alipaysubmit" action="`)
+	form.WriteString(html.EscapeString(pay.gateway() + "?charset=" + params["charset"]))
+	form.WriteString(`" method="POST">`)
+	for _, key := range keys {
+		form.WriteString(`<input type="hidden" name="`)
+		form.WriteString(html.EscapeString(key))
+		form.WriteString(`" value="`)
+		form.WriteString(html.EscapeString(params[key]))
+		form.WriteString(`"/>`)
+	}
+	form.WriteString(`<input type="submit" value="ok" style="display:none;"></form>`)
+	form.WriteString(`<script>document.forms['alipaysubmit'].submit();</script>`)
+
+	return form.String(), nil
+}
+
+// pageParams 生成页面跳转类接口的已签名参数
+// @params method string 接口方法
+// @params bizContent map[string]interface{} 业务数据
+func (pay *AliPay) pageParams(method string, bizContent map[string]interface{}) (map[string]string, error) {
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	return pay.signedParams(method, string(biz), nil)
+}
+
+// withBizDefault 业务数据未指定key时使用默认值，不修改调用方传入的业务数据
+func withBizDefault(bizContent map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	if _, ok := bizContent[key]; ok {
+		return bizContent
+	}
+
+	biz := make(map[string]interface{}, len(bizContent)+1)
+	for k, v := range bizContent {
+		biz[k] = v
+	}
+	biz[key] = value
+
+	return biz
+}
@@ -0,0 +1,24 @@
+package alipay
+
+import "testing"
+
+func TestPageFormGbk(t *testing.T) {
+	pay, _ := newTestAliPay(t)
+	biz := map[string]interface{}{
+		"out_trade_no": "20240101000001",
+		"total_amount": "9.90",
+		"subject":      "测试商品",
+	}
+
+	if _, err := pay.pageForm("alipay.trade.page.pay", biz); err != nil {
+		t.Fatalf("pageForm() error = %v", err)
+	}
+
+	pay.config.charset = CharsetGbk
+	if _, err := pay.pageForm("alipay.trade.page.pay", biz); err == nil {
+		t.Fatal("pageForm() with gbk want error")
+	}
+	if _, err := pay.pageUrl("alipay.trade.page.pay", biz); err != nil {
+		t.Fatalf("pageUrl() with gbk error = %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// RefundStatus 退款状态
+type RefundStatus string
+
+const (
+	RefundStatusSuccess RefundStatus = "REFUND_SUCCESS" // 退款处理成功
+)
+
+// RefundGoodsDetail 退款包含的商品列表信息
+type RefundGoodsDetail = GoodsDetail
+
+// RefundRoyaltyParameter 退分账明细信息
+type RefundRoyaltyParameter = RoyaltyParameter
+
+// TradeRefundRequest 统一收单交易退款请求参数
+type TradeRefundRequest struct {
+	OutTradeNo              string                   `json:"out_trade_no,omitempty" validate:"max=64"`
+	TradeNo                 string                   `json:"trade_no,omitempty" validate:"max=64"`
+	RefundAmount            string                   `json:"refund_amount" validate:"required,amount"`
+	RefundReason            string                   `json:"refund_reason,omitempty" validate:"max=256"`
+	OutRequestNo            string                   `json:"out_request_no,omitempty" validate:"max=64"`
+	RefundGoodsDetail       []RefundGoodsDetail      `json:"refund_goods_detail,omitempty"`
+	RefundRoyaltyParameters []RefundRoyaltyParameter `json:"refund_royalty_parameters,omitempty"`
+	QueryOptions            []string                 `json:"query_options,omitempty"`
+	OperatorId              string                   `json:"operator_id,omitempty" validate:"max=30"`
+	StoreId                 string                   `json:"store_id,omitempty" validate:"max=32"`
+	TerminalId              string                   `json:"terminal_id,omitempty" validate:"max=32"`
+}
+
+// Validate 校验统一收单交易退款请求参数
+func (req *TradeRefundRequest) Validate() error {
+	if req.OutTradeNo == "" && req.TradeNo == "" {
+		return errors.New("out_trade_no 和 trade_no 不能同时为空")
+	}
+	return validateFields(req)
+}
+
+// refundResponse 退款响应参数
+type refundResponse struct {
+	PayResponse
+	Response RefundResponseData `json:"alipay_trade_refund_response"`
+}
+
+// RefundResponseData 退款响应参数数据
+type RefundResponseData struct {
+	PayResponseData
+	TradeNo              string     `json:"trade_no"`
+	OutTradeNo           string     `json:"out_trade_no"`
+	BuyerLogonId         string     `json:"buyer_logon_id"`
+	BuyerUserId          string     `json:"buyer_user_id"`
+	BuyerOpenId          string     `json:"buyer_open_id"`
+	FundChange           string     `json:"fund_change"` // 本次退款是否发生了资金变化，重复请求时为N
+	RefundFee            string     `json:"refund_fee"`  // 交易累计退款金额，由退款查询确认结果时为空
+	SendBackFee          string     `json:"send_back_fee"`
+	StoreName            string     `json:"store_name"`
+	RefundDetailItemList []FundBill `json:"refund_detail_item_list"`
+}
+
+// refundQueryResponse 退款查询响应参数
+type refundQueryResponse struct {
+	PayResponse
+	Response RefundQueryResponseData `json:"alipay_trade_fastpay_refund_query_response"`
+}
+
+// RefundRoyaltyResult 退分账结果
+type RefundRoyaltyResult struct {
+	RefundAmount  string `json:"refund_amount"`
+	RoyaltyType   string `json:"royalty_type"`
+	ResultCode    string `json:"result_code"`
+	TransOut      string `json:"trans_out"`
+	TransOutEmail string `json:"trans_out_email"`
+	TransIn       string `json:"trans_in"`
+	TransInEmail  string `json:"trans_in_email"`
+}
+
+// RefundQueryResponseData 退款查询响应参数数据
+type RefundQueryResponseData struct {
+	PayResponseData
+	TradeNo              string                `json:"trade_no"`
+	OutTradeNo           string                `json:"out_trade_no"`
+	OutRequestNo         string                `json:"out_request_no"`
+	TotalAmount          string                `json:"total_amount"`
+	RefundAmount         string                `json:"refund_amount"`
+	RefundStatus         RefundStatus          `json:"refund_status"`
+	RefundReason         string                `json:"refund_reason"`
+	GmtRefundPay         string                `json:"gmt_refund_pay"`
+	SendBackFee          string                `json:"send_back_fee"`
+	RefundRoyaltys       []RefundRoyaltyResult `json:"refund_royaltys"`
+	RefundDetailItemList []FundBill            `json:"refund_detail_item_list"`
+}
+
+// Refund 统一收单交易退款
+// 部分退款需传入 out_request_no，同一笔退款请求重复调用时支付宝不会重复退款（fund_change=N），
+// 若退款接口请求失败或返回处理结果未知的错误，会以相同 out_request_no 查询退款结果，已退款成功时视为本次退款成功
+// @params bizContent interface{} 业务数据，*TradeRefundRequest 或 map[string]interface{}
+func (pay *AliPay) Refund(ctx context.Context, bizContent interface{}) (*RefundResponseData, error) {
+	m, err := bizMap(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	biz, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := pay.refund(ctx, string(biz))
+	if err != nil {
+		// 业务失败（如相同 out_request_no 金额不一致）须返回原始错误，仅处理结果未知时查询确认
+		outRequestNo, _ := m["out_request_no"].(string)
+		if outRequestNo == "" || !isResultUnknown(err) {
+			return nil, err
+		}
+
+		outTradeNo, _ := m["out_trade_no"].(string)
+		tradeNo, _ := m["trade_no"].(string)
+		refund, queryErr := pay.RefundQuery(ctx, outTradeNo, tradeNo, outRequestNo)
+		if queryErr != nil || refund.RefundStatus != RefundStatusSuccess {
+			return nil, err
+		}
+
+		return &RefundResponseData{
+			PayResponseData: refund.PayResponseData,
+			TradeNo:         refund.TradeNo,
+			OutTradeNo:      refund.OutTradeNo,
+			FundChange:      "N",
+			SendBackFee:     refund.SendBackFee,
+		}, nil
+	}
+
+	return data, nil
+}
+
+// refund 发起一次退款请求
+// @params bizContent string 业务数据
+func (pay *AliPay) refund(ctx context.Context, bizContent string) (*RefundResponseData, error) {
+	response, err := pay.call(ctx, "alipay.trade.refund", bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	var result refundResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
+
+// RefundQuery 统一收单交易退款查询
+// @params outTradeNo string 商户订单号，与tradeNo二选一
+// @params tradeNo string 支付宝交易号，与outTradeNo二选一
+// @params outRequestNo string 退款请求号，未传入时为商户订单号
+// @params queryOptions ...string 查询选项，如 gmt_refund_pay、refund_detail_item_list
+func (pay *AliPay) RefundQuery(ctx context.Context, outTradeNo, tradeNo, outRequestNo string, queryOptions ...string) (*RefundQueryResponseData, error) {
+	if outTradeNo == "" && tradeNo == "" {
+		return nil, errors.New("out_trade_no 和 trade_no 不能同时为空")
+	}
+	if outRequestNo == "" {
+		if outTradeNo == "" {
+			return nil, errors.New("out_request_no 不能为空")
+		}
+		outRequestNo = outTradeNo
+	}
+
+	bizContent := map[string]interface{}{
+		"out_request_no": outRequestNo,
+	}
+	if outTradeNo != "" {
+		bizContent["out_trade_no"] = outTradeNo
+	}
+	if tradeNo != "" {
+		bizContent["trade_no"] = tradeNo
+	}
+	if len(queryOptions) > 0 {
+		bizContent["query_options"] = queryOptions
+	}
+
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.trade.fastpay.refund.query", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result refundQueryResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
@@ -0,0 +1,194 @@
+package alipay
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Validator 业务请求参数校验，请求结构体在调用接口前完成必填项及长度校验
+type Validator interface {
+	Validate() error
+}
+
+// GoodsDetail 订单包含的商品列表信息
+type GoodsDetail struct {
+	GoodsId        string `json:"goods_id" validate:"required,max=64"`
+	AlipayGoodsId  string `json:"alipay_goods_id,omitempty" validate:"max=32"`
+	GoodsName      string `json:"goods_name" validate:"required,max=256"`
+	Quantity       int    `json:"quantity" validate:"required"`
+	Price          string `json:"price" validate:"required,amount"`
+	GoodsCategory  string `json:"goods_category,omitempty" validate:"max=24"`
+	CategoriesTree string `json:"categories_tree,omitempty" validate:"max=128"`
+	Body           string `json:"body,omitempty" validate:"max=1000"`
+	ShowUrl        string `json:"show_url,omitempty" validate:"max=400"`
+}
+
+// ExtendParams 业务扩展参数
+type ExtendParams struct {
+	SysServiceProviderId string `json:"sys_service_provider_id,omitempty" validate:"max=64"`
+	HbFqNum              string `json:"hb_fq_num,omitempty" validate:"max=5"`
+	HbFqSellerPercent    string `json:"hb_fq_seller_percent,omitempty" validate:"max=3"`
+	IndustryRefluxInfo   string `json:"industry_reflux_info,omitempty" validate:"max=512"`
+	CardType             string `json:"card_type,omitempty" validate:"max=32"`
+	SpecifiedSellerName  string `json:"specified_seller_name,omitempty" validate:"max=32"`
+	RoyaltyFreeze        string `json:"royalty_freeze,omitempty" validate:"max=5"` // 是否冻结分账资金，true时交易资金冻结待结算分账
+}
+
+// SettleInfo 结算信息
+type SettleInfo struct {
+	SettleDetailInfos []SettleDetailInfo `json:"settle_detail_infos" validate:"required,max=10"`
+	SettlePeriodTime  string             `json:"settle_period_time,omitempty" validate:"max=10"`
+}
+
+// SettleDetailInfo 结算详细信息
+type SettleDetailInfo struct {
+	TransInType      string `json:"trans_in_type" validate:"required,max=64"`
+	TransIn          string `json:"trans_in" validate:"required,max=64"`
+	SummaryDimension string `json:"summary_dimension,omitempty" validate:"max=64"`
+	SettleEntityId   string `json:"settle_entity_id,omitempty" validate:"max=64"`
+	SettleEntityType string `json:"settle_entity_type,omitempty" validate:"max=32"`
+	Amount           string `json:"amount" validate:"required,amount"`
+}
+
+// BusinessParams 商户传入业务信息
+type BusinessParams struct {
+	CampusCard      string `json:"campus_card,omitempty" validate:"max=64"`
+	CardType        string `json:"card_type,omitempty" validate:"max=128"`
+	ActualOrderTime string `json:"actual_order_time,omitempty" validate:"max=256"`
+	GoodTaxes       string `json:"good_taxes,omitempty" validate:"max=32"`
+	McCreateTradeIp string `json:"mc_create_trade_ip,omitempty" validate:"max=128"`
+}
+
+// amountPattern 金额格式，单位为元，精确到小数点后两位
+var amountPattern = regexp.MustCompile(`^\d{1,9}(\.\d{1,2})?$`)
+
+// marshalBiz 校验业务数据并序列化为JSON
+// @params bizContent interface{} 业务数据，请求结构体或 map[string]interface{}
+func marshalBiz(bizContent interface{}) (string, error) {
+	if bizContent == nil {
+		return "", nil
+	}
+	if v, ok := bizContent.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return "", err
+		}
+	}
+
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return "", err
+	}
+
+	return string(biz), nil
+}
+
+// bizMap 校验业务数据并转换为map，便于读取或补全业务字段
+// @params bizContent interface{} 业务数据，请求结构体或 map[string]interface{}
+func bizMap(bizContent interface{}) (map[string]interface{}, error) {
+	if m, ok := bizContent.(map[string]interface{}); ok {
+		return m, nil
+	}
+
+	biz, err := marshalBiz(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader([]byte(biz)))
+	decoder.UseNumber()
+	if err = decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// validateFields 按 validate 标签校验结构体字段
+// 支持 required（必填）、max（字符串最大字符数或列表最大长度）、amount（金额格式）
+func validateFields(v interface{}) error {
+	return validateValue(reflect.ValueOf(v), "")
+}
+
+// validateValue 递归校验结构体、指针及列表中的字段
+func validateValue(value reflect.Value, path string) error {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return validateValue(value.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := validateValue(value.Index(i), path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := value.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				name = field.Name
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+
+			if err := validateField(value.Field(i), field.Tag.Get("validate"), name); err != nil {
+				return err
+			}
+			if err := validateValue(value.Field(i), name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateField 按校验规则校验单个字段
+func validateField(value reflect.Value, rules, name string) error {
+	if rules == "" {
+		return nil
+	}
+
+	for _, rule := range strings.Split(rules, ",") {
+		switch {
+		case rule == "required":
+			if value.IsZero() {
+				return errors.Errorf("%s 不能为空", name)
+			}
+		case rule == "amount":
+			if value.Kind() == reflect.String && value.String() != "" && !amountPattern.MatchString(value.String()) {
+				return errors.Errorf("%s 金额格式错误: %s", name, value.String())
+			}
+		case strings.HasPrefix(rule, "max="):
+			max, err := strconv.Atoi(strings.TrimPrefix(rule, "max="))
+			if err != nil {
+				return errors.Errorf("%s 校验规则错误: %s", name, rule)
+			}
+
+			length := 0
+			switch value.Kind() {
+			case reflect.String:
+				length = utf8.RuneCountInString(value.String())
+			case reflect.Slice, reflect.Array, reflect.Map:
+				length = value.Len()
+			}
+			if length > max {
+				return errors.Errorf("%s 长度不能超过 %d", name, max)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,286 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// RoyaltyParameter 分账明细信息
+type RoyaltyParameter struct {
+	RoyaltyType  string `json:"royalty_type,omitempty" validate:"max=32"`
+	TransOut     string `json:"trans_out,omitempty" validate:"max=64"`
+	TransOutType string `json:"trans_out_type,omitempty" validate:"max=64"`
+	TransInType  string `json:"trans_in_type,omitempty" validate:"max=64"`
+	TransIn      string `json:"trans_in,omitempty" validate:"max=64"`
+	Amount       string `json:"amount,omitempty" validate:"amount"`
+	Desc         string `json:"desc,omitempty" validate:"max=1000"`
+	RoyaltyScene string `json:"royalty_scene,omitempty" validate:"max=256"`
+	TransInName  string `json:"trans_in_name,omitempty" validate:"max=64"`
+}
+
+// RoyaltyEntity 分账接收方
+type RoyaltyEntity struct {
+	Type          string `json:"type" validate:"required,max=64"` // userId、loginName、openId
+	Account       string `json:"account" validate:"required,max=128"`
+	Name          string `json:"name,omitempty" validate:"max=64"`
+	Memo          string `json:"memo,omitempty" validate:"max=1000"`
+	LoginName     string `json:"login_name,omitempty" validate:"max=64"`
+	BindLoginName string `json:"bind_login_name,omitempty" validate:"max=64"`
+}
+
+// OrderSettleExtendParams 分账扩展参数
+type OrderSettleExtendParams struct {
+	RoyaltyFinish string `json:"royalty_finish,omitempty" validate:"max=5"` // 是否完结分账，true时解冻剩余冻结金额
+}
+
+// OrderSettleRequest 统一收单交易结算请求参数
+type OrderSettleRequest struct {
+	OutRequestNo      string                   `json:"out_request_no" validate:"required,max=64"`
+	TradeNo           string                   `json:"trade_no" validate:"required,max=64"`
+	RoyaltyParameters []RoyaltyParameter       `json:"royalty_parameters" validate:"required"`
+	OperatorId        string                   `json:"operator_id,omitempty" validate:"max=64"`
+	ExtendParams      *OrderSettleExtendParams `json:"extend_params,omitempty"`
+	RoyaltyMode       string                   `json:"royalty_mode,omitempty" validate:"max=64"` // sync 同步分账，async 异步分账
+}
+
+// Validate 校验统一收单交易结算请求参数
+func (req *OrderSettleRequest) Validate() error {
+	return validateFields(req)
+}
+
+// orderSettleResponse 统一收单交易结算响应参数
+type orderSettleResponse struct {
+	PayResponse
+	Response OrderSettleResponseData `json:"alipay_trade_order_settle_response"`
+}
+
+// OrderSettleResponseData 统一收单交易结算响应参数数据
+type OrderSettleResponseData struct {
+	PayResponseData
+	TradeNo  string `json:"trade_no"`
+	SettleNo string `json:"settle_no"`
+}
+
+// orderSettleQueryResponse 交易分账查询响应参数
+type orderSettleQueryResponse struct {
+	PayResponse
+	Response OrderSettleQueryResponseData `json:"alipay_trade_order_settle_query_response"`
+}
+
+// RoyaltyDetail 分账明细
+type RoyaltyDetail struct {
+	OperationType string `json:"operation_type"`
+	ExecuteDt     string `json:"execute_dt"`
+	TransOut      string `json:"trans_out"`
+	TransOutType  string `json:"trans_out_type"`
+	TransIn       string `json:"trans_in"`
+	TransInType   string `json:"trans_in_type"`
+	Amount        string `json:"amount"`
+	State         string `json:"state"` // PROCESSING、SUCCESS、FAIL
+	DetailId      string `json:"detail_id"`
+	ErrorCode     string `json:"error_code"`
+	ErrorDesc     string `json:"error_desc"`
+}
+
+// OrderSettleQueryResponseData 交易分账查询响应参数数据
+type OrderSettleQueryResponseData struct {
+	PayResponseData
+	OutRequestNo      string          `json:"out_request_no"`
+	OperationDt       string          `json:"operation_dt"`
+	RoyaltyDetailList []RoyaltyDetail `json:"royalty_detail_list"`
+}
+
+// royaltyRelationResponse 分账关系绑定与解绑响应参数，按接口方法返回对应节点
+type royaltyRelationResponse struct {
+	PayResponse
+	BindResponse   RoyaltyRelationResponseData `json:"alipay_trade_royalty_relation_bind_response"`
+	UnbindResponse RoyaltyRelationResponseData `json:"alipay_trade_royalty_relation_unbind_response"`
+}
+
+// RoyaltyRelationResponseData 分账关系绑定与解绑响应参数数据
+type RoyaltyRelationResponseData struct {
+	PayResponseData
+	ResultCode string `json:"result_code"` // SUCCESS、FAIL
+}
+
+// royaltyRelationBatchQueryResponse 分账关系查询响应参数
+type royaltyRelationBatchQueryResponse struct {
+	PayResponse
+	Response RoyaltyRelationBatchQueryResponseData `json:"alipay_trade_royalty_relation_batchquery_response"`
+}
+
+// RoyaltyRelationBatchQueryResponseData 分账关系查询响应参数数据
+type RoyaltyRelationBatchQueryResponseData struct {
+	PayResponseData
+	ResultCode      string          `json:"result_code"`
+	ReceiverList    []RoyaltyEntity `json:"receiver_list"`
+	TotalPageNum    int             `json:"total_page_num"`
+	TotalRecordNum  int             `json:"total_record_num"`
+	CurrentPageNum  int             `json:"current_page_num"`
+	CurrentPageSize int             `json:"current_page_size"`
+}
+
+// OrderSettle 统一收单交易结算，将冻结的交易资金分账给分账接收方
+// @params bizContent interface{} 业务数据，*OrderSettleRequest 或 map[string]interface{}
+func (pay *AliPay) OrderSettle(ctx context.Context, bizContent interface{}) (*OrderSettleResponseData, error) {
+	biz, err := marshalBiz(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.trade.order.settle", biz)
+	if err != nil {
+		return nil, err
+	}
+
+	var result orderSettleResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
+
+// OrderSettleQuery 交易分账查询
+// @params settleNo string 支付宝分账单号，与outRequestNo、tradeNo二选一
+// @params outRequestNo string 分账请求单号，须与tradeNo同时传入
+// @params tradeNo string 支付宝交易号
+func (pay *AliPay) OrderSettleQuery(ctx context.Context, settleNo, outRequestNo, tradeNo string) (*OrderSettleQueryResponseData, error) {
+	bizContent := map[string]interface{}{}
+	switch {
+	case settleNo != "":
+		bizContent["settle_no"] = settleNo
+	case outRequestNo != "" && tradeNo != "":
+		bizContent["out_request_no"] = outRequestNo
+		bizContent["trade_no"] = tradeNo
+	default:
+		return nil, errors.New("settle_no 为空时 out_request_no 和 trade_no 不能为空")
+	}
+
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.trade.order.settle.query", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result orderSettleQueryResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
+
+// RoyaltyRelationBind 分账关系绑定
+// @params outRequestNo string 外部请求号
+// @params receivers []RoyaltyEntity 分账接收方列表
+func (pay *AliPay) RoyaltyRelationBind(ctx context.Context, outRequestNo string, receivers []RoyaltyEntity) (*RoyaltyRelationResponseData, error) {
+	return pay.royaltyRelation(ctx, "alipay.trade.royalty.relation.bind", outRequestNo, receivers)
+}
+
+// RoyaltyRelationUnbind 分账关系解绑
+// @params outRequestNo string 外部请求号
+// @params receivers []RoyaltyEntity 分账接收方列表
+func (pay *AliPay) RoyaltyRelationUnbind(ctx context.Context, outRequestNo string, receivers []RoyaltyEntity) (*RoyaltyRelationResponseData, error) {
+	return pay.royaltyRelation(ctx, "alipay.trade.royalty.relation.unbind", outRequestNo, receivers)
+}
+
+// royaltyRelation 分账关系绑定与解绑
+func (pay *AliPay) royaltyRelation(ctx context.Context, method, outRequestNo string, receivers []RoyaltyEntity) (*RoyaltyRelationResponseData, error) {
+	if outRequestNo == "" {
+		return nil, errors.New("out_request_no 不能为空")
+	}
+	if len(receivers) == 0 {
+		return nil, errors.New("receiver_list 不能为空")
+	}
+	if err := validateFields(receivers); err != nil {
+		return nil, err
+	}
+
+	biz, err := json.Marshal(map[string]interface{}{
+		"out_request_no": outRequestNo,
+		"receiver_list":  receivers,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, method, string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result royaltyRelationResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &result.BindResponse
+	if method == "alipay.trade.royalty.relation.unbind" {
+		data = &result.UnbindResponse
+	}
+	if err = data.err(response); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// RoyaltyRelationBatchQuery 分账关系查询
+// @params outRequestNo string 外部请求号
+// @params pageNum int 页码，从1开始
+// @params pageSize int 每页条数，最大100
+func (pay *AliPay) RoyaltyRelationBatchQuery(ctx context.Context, outRequestNo string, pageNum, pageSize int) (*RoyaltyRelationBatchQueryResponseData, error) {
+	if outRequestNo == "" {
+		return nil, errors.New("out_request_no 不能为空")
+	}
+
+	bizContent := map[string]interface{}{
+		"out_request_no": outRequestNo,
+	}
+	if pageNum > 0 {
+		bizContent["page_num"] = pageNum
+	}
+	if pageSize > 0 {
+		bizContent["page_size"] = pageSize
+	}
+
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.trade.royalty.relation.batchquery", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result royaltyRelationBatchQueryResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
@@ -0,0 +1,289 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	transferProductCode = "TRANS_ACCOUNT_NO_PWD" // 单笔无密转账产品码
+	transferBizScene    = "DIRECT_TRANSFER"      // 单笔无密转账到支付宝账户场景
+)
+
+// ErrCertModeRequired 接口须使用公钥证书模式
+var ErrCertModeRequired = errors.New("该接口须使用公钥证书模式，请使用 WithCert 或 WithCertFile 设置证书")
+
+// IdentityType 参与方标识类型
+type IdentityType string
+
+const (
+	IdentityTypeUserId  IdentityType = "ALIPAY_USER_ID"  // 支付宝用户UID
+	IdentityTypeLogonId IdentityType = "ALIPAY_LOGON_ID" // 支付宝登录号，须同时传入真实姓名
+	IdentityTypeOpenId  IdentityType = "ALIPAY_OPEN_ID"  // 支付宝openId
+)
+
+// TransferStatus 转账单据状态
+type TransferStatus string
+
+const (
+	TransferStatusSuccess TransferStatus = "SUCCESS" // 成功
+	TransferStatusDealing TransferStatus = "DEALING" // 处理中
+	TransferStatusRefund  TransferStatus = "REFUND"  // 退票
+	TransferStatusFail    TransferStatus = "FAIL"    // 失败
+)
+
+// Participant 转账参与方
+type Participant struct {
+	Identity     string       `json:"identity" validate:"required,max=64"`
+	IdentityType IdentityType `json:"identity_type" validate:"required"`
+	Name         string       `json:"name,omitempty" validate:"max=128"`
+}
+
+// FundTransferRequest 单笔转账请求参数
+type FundTransferRequest struct {
+	OutBizNo       string       `json:"out_biz_no" validate:"required,max=64"`
+	TransAmount    string       `json:"trans_amount" validate:"required,amount"`
+	ProductCode    string       `json:"product_code,omitempty" validate:"max=64"`
+	BizScene       string       `json:"biz_scene,omitempty" validate:"max=64"`
+	OrderTitle     string       `json:"order_title,omitempty" validate:"max=128"`
+	PayeeInfo      *Participant `json:"payee_info" validate:"required"`
+	Remark         string       `json:"remark,omitempty" validate:"max=200"`
+	BusinessParams string       `json:"business_params,omitempty" validate:"max=2048"`
+}
+
+// Validate 校验单笔转账请求参数
+func (req *FundTransferRequest) Validate() error {
+	if err := validateFields(req); err != nil {
+		return err
+	}
+
+	switch req.PayeeInfo.IdentityType {
+	case IdentityTypeUserId, IdentityTypeOpenId:
+	case IdentityTypeLogonId:
+		if req.PayeeInfo.Name == "" {
+			return errors.New("payee_info.name 支付宝登录号转账须传入收款方真实姓名")
+		}
+	default:
+		return errors.Errorf("payee_info.identity_type 不支持: %s", req.PayeeInfo.IdentityType)
+	}
+
+	return nil
+}
+
+// transferResponse 单笔转账响应参数
+type transferResponse struct {
+	PayResponse
+	Response TransferResponseData `json:"alipay_fund_trans_uni_transfer_response"`
+}
+
+// TransferResponseData 单笔转账响应参数数据
+type TransferResponseData struct {
+	PayResponseData
+	OutBizNo       string         `json:"out_biz_no"`
+	OrderId        string         `json:"order_id"`
+	PayFundOrderId string         `json:"pay_fund_order_id"`
+	Status         TransferStatus `json:"status"`
+	TransDate      string         `json:"trans_date"`
+}
+
+// transferQueryResponse 转账业务单据查询响应参数
+type transferQueryResponse struct {
+	PayResponse
+	Response TransferQueryResponseData `json:"alipay_fund_trans_common_query_response"`
+}
+
+// TransferQueryResponseData 转账业务单据查询响应参数数据
+type TransferQueryResponseData struct {
+	PayResponseData
+	OrderId          string         `json:"order_id"`
+	PayFundOrderId   string         `json:"pay_fund_order_id"`
+	OutBizNo         string         `json:"out_biz_no"`
+	TransAmount      string         `json:"trans_amount"`
+	Status           TransferStatus `json:"status"`
+	PayDate          string         `json:"pay_date"`
+	ArrivalTimeEnd   string         `json:"arrival_time_end"`
+	OrderFee         string         `json:"order_fee"`
+	ErrorCode        string         `json:"error_code"`
+	FailReason       string         `json:"fail_reason"`
+	DeductBillInfo   string         `json:"deduct_bill_info"`
+	TransferBillInfo string         `json:"transfer_bill_info"`
+}
+
+// accountQueryResponse 支付宝资金账户资产查询响应参数
+type accountQueryResponse struct {
+	PayResponse
+	Response AccountQueryResponseData `json:"alipay_fund_account_query_response"`
+}
+
+// AccountQueryResponseData 支付宝资金账户资产查询响应参数数据
+type AccountQueryResponseData struct {
+	PayResponseData
+	AvailableAmount string `json:"available_amount"`
+	FreezeAmount    string `json:"freeze_amount"`
+}
+
+// Transfer 单笔转账到支付宝账户，须使用公钥证书模式
+// 相同 out_biz_no 重复请求时支付宝不会重复转账，若转账接口请求失败或返回处理结果未知的错误，
+// 会以相同 out_biz_no、product_code 及 biz_scene 查询转账结果，已转账成功时视为本次转账成功
+// @params bizContent interface{} 业务数据，*FundTransferRequest 或 map[string]interface{}
+func (pay *AliPay) Transfer(ctx context.Context, bizContent interface{}) (*TransferResponseData, error) {
+	if !pay.isCertMode() {
+		return nil, ErrCertModeRequired
+	}
+
+	m, err := bizMap(bizContent)
+	if err != nil {
+		return nil, err
+	}
+	m = withBizDefault(m, "product_code", transferProductCode)
+	m = withBizDefault(m, "biz_scene", transferBizScene)
+
+	biz, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := pay.transfer(ctx, string(biz))
+	if err != nil {
+		// 业务失败须返回原始错误，仅处理结果未知时查询确认
+		outBizNo, _ := m["out_biz_no"].(string)
+		if outBizNo == "" || !isResultUnknown(err) {
+			return nil, err
+		}
+
+		// 查询须使用与转账相同的产品码及业务场景
+		transfer, queryErr := pay.transferQuery(ctx, map[string]interface{}{
+			"out_biz_no":   outBizNo,
+			"product_code": m["product_code"],
+			"biz_scene":    m["biz_scene"],
+		})
+		if queryErr != nil || transfer.Status != TransferStatusSuccess {
+			return nil, err
+		}
+
+		return &TransferResponseData{
+			PayResponseData: transfer.PayResponseData,
+			OutBizNo:        transfer.OutBizNo,
+			OrderId:         transfer.OrderId,
+			PayFundOrderId:  transfer.PayFundOrderId,
+			Status:          transfer.Status,
+			TransDate:       transfer.PayDate,
+		}, nil
+	}
+
+	return data, nil
+}
+
+// transfer 调用单笔转账接口
+// @params bizContent string 业务数据
+func (pay *AliPay) transfer(ctx context.Context, bizContent string) (*TransferResponseData, error) {
+	response, err := pay.call(ctx, "alipay.fund.trans.uni.transfer", bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	var result transferResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
+
+// TransferQuery 转账业务单据查询，须使用公钥证书模式
+// @params outBizNo string 商户转账单号，与orderId二选一
+// @params orderId string 支付宝转账单据号，与outBizNo二选一
+func (pay *AliPay) TransferQuery(ctx context.Context, outBizNo, orderId string) (*TransferQueryResponseData, error) {
+	if !pay.isCertMode() {
+		return nil, ErrCertModeRequired
+	}
+	if outBizNo == "" && orderId == "" {
+		return nil, errors.New("out_biz_no 和 order_id 不能同时为空")
+	}
+
+	bizContent := map[string]interface{}{
+		"product_code": transferProductCode,
+		"biz_scene":    transferBizScene,
+	}
+	if outBizNo != "" {
+		bizContent["out_biz_no"] = outBizNo
+	}
+	if orderId != "" {
+		bizContent["order_id"] = orderId
+	}
+
+	return pay.transferQuery(ctx, bizContent)
+}
+
+// transferQuery 调用转账业务单据查询接口
+// @params bizContent map[string]interface{} 业务数据
+func (pay *AliPay) transferQuery(ctx context.Context, bizContent map[string]interface{}) (*TransferQueryResponseData, error) {
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.fund.trans.common.query", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result transferQueryResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
+
+// AccountQuery 支付宝资金账户资产查询，须使用公钥证书模式
+// @params alipayUserId string 支付宝会员ID
+// @params accountType string 账户类型，为空时查询余额户 ACCTRANS_ACCOUNT
+func (pay *AliPay) AccountQuery(ctx context.Context, alipayUserId, accountType string) (*AccountQueryResponseData, error) {
+	if !pay.isCertMode() {
+		return nil, ErrCertModeRequired
+	}
+	if alipayUserId == "" {
+		return nil, errors.New("alipay_user_id 不能为空")
+	}
+	if accountType == "" {
+		accountType = "ACCTRANS_ACCOUNT"
+	}
+
+	biz, err := json.Marshal(map[string]interface{}{
+		"alipay_user_id": alipayUserId,
+		"account_type":   accountType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := pay.call(ctx, "alipay.fund.account.query", string(biz))
+	if err != nil {
+		return nil, err
+	}
+
+	var result accountQueryResponse
+	err = json.Unmarshal(response, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Response.err(response); err != nil {
+		return nil, err
+	}
+
+	return &result.Response, nil
+}
@@ -0,0 +1,233 @@
+package alipay
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/dysodeng/payment/support/crypto/rsa"
+	"github.com/pkg/errors"
+)
+
+// errorResponseNode 网关级错误响应节点
+const errorResponseNode = "error_response"
+
+// ErrResponseSign 同步响应验签失败
+var ErrResponseSign = errors.New("支付宝响应验签失败")
+
+// verifyResponse 同步响应验签
+// 截取响应中 <method>_response 节点的原始JSON作为待验签内容，使用支付宝公钥验证 sign，返回已验签的节点
+// @params method string 接口方法
+// @params body []byte 响应原文
+// @params charset string 响应编码
+func (pay *AliPay) verifyResponse(ctx context.Context, method string, body []byte, charset string) ([]byte, error) {
+	content, err := responseNode(body, responseNodeName(method), charset)
+	if err != nil {
+		// 网关级错误（如应用ID、签名错误）返回 error_response 节点，不包含业务数据
+		errContent, nodeErr := responseNode(body, errorResponseNode, charset)
+		if nodeErr != nil {
+			return nil, errors.Wrapf(ErrResponseSign, "%v", err)
+		}
+
+		var data PayResponseData
+		if err = json.Unmarshal(errContent, &data); err != nil {
+			return nil, err
+		}
+		if err = data.err(body); err != nil {
+			return nil, err
+		}
+		return nil, errors.Wrapf(ErrResponseSign, "%s", errorResponseNode)
+	}
+
+	// GBK响应中的尾字节可能被标准JSON解析视为转义符，签名及证书序列号同样按编码截取
+	var result PayResponse
+	if sign, signErr := responseNode(body, "sign", charset); signErr == nil {
+		if err = json.Unmarshal(sign, &result.Sign); err != nil {
+			return nil, errors.Wrapf(ErrResponseSign, "%s 响应签名格式错误", method)
+		}
+	}
+	if certSn, certSnErr := responseNode(body, "alipay_cert_sn", charset); certSnErr == nil {
+		if err = json.Unmarshal(certSn, &result.AlipayCertSn); err != nil {
+			return nil, errors.Wrapf(ErrResponseSign, "%s 响应证书序列号格式错误", method)
+		}
+	}
+
+	// 业务响应节点必须验签，否则可被伪造为交易已支付、系统繁忙等失败结果
+	if result.Sign == "" {
+		return nil, errors.Wrapf(ErrResponseSign, "%s 响应缺少签名", method)
+	}
+
+	publicKey, err := pay.alipayPublicKeyFor(ctx, result.AlipayCertSn, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := rsa.CheckWithKey(string(content), result.Sign, publicKey)
+	if err != nil || !ok {
+		return nil, errors.Wrapf(ErrResponseSign, "%s", method)
+	}
+
+	return content, nil
+}
+
+// verifiedBody 使用已验签的节点重新组装响应，响应原文中的其他内容不参与解析
+// @params method string 接口方法
+// @params node []byte 已验签的响应节点
+func verifiedBody(method string, node []byte) []byte {
+	name := responseNodeName(method)
+	body := make([]byte, 0, len(name)+len(node)+5)
+	body = append(body, `{"`+name+`":`...)
+	body = append(body, node...)
+	return append(body, '}')
+}
+
+// responseNodeName 接口方法对应的响应节点名称
+// @params method string 接口方法，如 alipay.trade.query 对应 alipay_trade_query_response
+func responseNodeName(method string) string {
+	return strings.ReplaceAll(method, ".", "_") + "_response"
+}
+
+// responseNode 截取响应原文中指定节点的原始值
+// @params body []byte 响应原文
+// @params node string 节点名称
+// @params charset string 响应编码
+func responseNode(body []byte, node, charset string) ([]byte, error) {
+	start, end, err := responseNodeIndex(body, node, charset)
+	if err != nil {
+		return nil, err
+	}
+
+	return body[start:end], nil
+}
+
+// responseNodeIndex 计算响应原文中指定顶层节点原始值的起止位置
+// 逐个扫描顶层键，节点重复出现时返回错误，避免验签与解析使用不同的节点
+// @params body []byte 响应原文
+// @params node string 节点名称
+// @params charset string 响应编码
+func responseNodeIndex(body []byte, node, charset string) (start, end int, err error) {
+	gbk := isGbk(charset)
+	start, end = -1, -1
+
+	i := skipJsonSpace(body, 0)
+	if i >= len(body) || body[i] != '{' {
+		return 0, 0, errors.New("响应格式错误")
+	}
+	i = skipJsonSpace(body, i+1)
+	for i < len(body) && body[i] != '}' {
+		keyEnd := jsonValueEnd(body[i:], gbk)
+		if body[i] != '"' || keyEnd < 0 {
+			return 0, 0, errors.New("响应格式错误")
+		}
+		var key string
+		if err = json.Unmarshal(body[i:i+keyEnd], &key); err != nil {
+			return 0, 0, errors.Wrap(err, "响应格式错误")
+		}
+
+		i = skipJsonSpace(body, i+keyEnd)
+		if i >= len(body) || body[i] != ':' {
+			return 0, 0, errors.Errorf("响应 %s 节点格式错误", key)
+		}
+		i = skipJsonSpace(body, i+1)
+		valueEnd := jsonValueEnd(body[i:], gbk)
+		if valueEnd < 0 {
+			return 0, 0, errors.Errorf("响应 %s 节点格式错误", key)
+		}
+
+		if key == node {
+			if start >= 0 {
+				return 0, 0, errors.Errorf("响应中 %s 节点重复", node)
+			}
+			start, end = i, i+valueEnd
+		}
+
+		i = skipJsonSpace(body, i+valueEnd)
+		if i < len(body) && body[i] == ',' {
+			i = skipJsonSpace(body, i+1)
+		} else if i >= len(body) || body[i] != '}' {
+			return 0, 0, errors.New("响应格式错误")
+		}
+	}
+	if i >= len(body) {
+		return 0, 0, errors.New("响应格式错误")
+	}
+
+	if start < 0 {
+		return 0, 0, errors.Errorf("响应中未找到 %s 节点", node)
+	}
+
+	return start, end, nil
+}
+
+// skipJsonSpace 跳过JSON空白字符，返回下一个非空白字符的位置
+func skipJsonSpace(data []byte, i int) int {
+	for i < len(data) && isJsonSpace(data[i]) {
+		i++
+	}
+	return i
+}
+
+// isJsonSpace 是否为JSON空白字符
+func isJsonSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// jsonValueEnd 计算数据开头的JSON值的结束位置
+// GBK编码时双字节字符的尾字节可能为 0x5C（\），字符串中遇到首字节 0x81-0xFE 时须跳过尾字节
+// @params data []byte JSON数据
+// @params gbk bool 数据是否为GBK编码
+func jsonValueEnd(data []byte, gbk bool) int {
+	if len(data) == 0 {
+		return -1
+	}
+
+	// 数字、true、false、null
+	if c := data[0]; c != '{' && c != '[' && c != '"' {
+		for i, c := range data {
+			if c == ',' || c == '}' || c == ']' || isJsonSpace(c) {
+				if i == 0 {
+					return -1
+				}
+				return i
+			}
+		}
+		return -1
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case gbk && c >= 0x81 && c <= 0xfe:
+				i++
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return -1
+}
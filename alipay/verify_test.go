@@ -0,0 +1,401 @@
+package alipay
+
+import (
+	"context"
+	"crypto/rand"
+	cryptoRsa "crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dysodeng/payment/support/crypto/rsa"
+	"github.com/pkg/errors"
+)
+
+// newTestAliPay 创建使用随机密钥的非证书模式客户端，返回客户端及模拟支付宝签名的私钥
+func newTestAliPay(t *testing.T, options ...Option) (*AliPay, *cryptoRsa.PrivateKey) {
+	t.Helper()
+
+	key, err := cryptoRsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pay, err := New(
+		"2021000000000000",
+		base64.StdEncoding.EncodeToString(publicKey),
+		base64.StdEncoding.EncodeToString(privateKey),
+		options...,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pay, key
+}
+
+// signTest 使用模拟支付宝私钥签名
+func signTest(t *testing.T, key *cryptoRsa.PrivateKey, content string) string {
+	t.Helper()
+
+	sign, err := rsa.SignWithKey(content, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sign
+}
+
+func TestResponseNode(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		node    string
+		charset string
+		want    string
+		err     bool
+	}{
+		{
+			name: "object",
+			body: `{"alipay_trade_query_response":{"code":"10000","msg":"Success"},"sign":"abc"}`,
+			node: "alipay_trade_query_response",
+			want: `{"code":"10000","msg":"Success"}`,
+		},
+		{
+			name: "whitespace around colon",
+			body: "{\"alipay_trade_query_response\" \n:\t {\"code\":\"10000\"} ,\"sign\":\"abc\"}",
+			node: "alipay_trade_query_response",
+			want: `{"code":"10000"}`,
+		},
+		{
+			name: "escaped quotes in string",
+			body: `{"alipay_trade_query_response":{"subject":"a\"}b\\","code":"10000"},"sign":"abc"}`,
+			node: "alipay_trade_query_response",
+			want: `{"subject":"a\"}b\\","code":"10000"}`,
+		},
+		{
+			name: "braces in string",
+			body: `{"alipay_trade_query_response":{"body":"{[}]{{","code":"10000"},"sign":"abc"}`,
+			node: "alipay_trade_query_response",
+			want: `{"body":"{[}]{{","code":"10000"}`,
+		},
+		{
+			name: "nested objects and arrays",
+			body: `{"alipay_trade_query_response":{"fund_bill_list":[{"amount":"1.00"},{"a":{"b":[1,2]}}],"code":"10000"},"sign":"abc"}`,
+			node: "alipay_trade_query_response",
+			want: `{"fund_bill_list":[{"amount":"1.00"},{"a":{"b":[1,2]}}],"code":"10000"}`,
+		},
+		{
+			name: "encrypted string node",
+			body: `{"alipay_trade_query_response":"4AOYvHA\/jZ+Y6\"x==","sign":"abc"}`,
+			node: "alipay_trade_query_response",
+			want: `"4AOYvHA\/jZ+Y6\"x=="`,
+		},
+		{
+			name: "error response",
+			body: `{"error_response":{"code":"40002","msg":"Invalid Arguments","sub_code":"isv.invalid-app-id"}}`,
+			node: errorResponseNode,
+			want: `{"code":"40002","msg":"Invalid Arguments","sub_code":"isv.invalid-app-id"}`,
+		},
+		{
+			name: "node not found",
+			body: `{"error_response":{"code":"40002"}}`,
+			node: "alipay_trade_query_response",
+			err:  true,
+		},
+		{
+			name:    "gbk trail byte 0x5c",
+			body:    "{\"alipay_trade_query_response\":{\"subject\":\"\x81\x5c\",\"body\":\"\x82\x5c}\",\"code\":\"10000\"},\"sign\":\"abc\"}",
+			node:    "alipay_trade_query_response",
+			charset: CharsetGbk,
+			want:    "{\"subject\":\"\x81\x5c\",\"body\":\"\x82\x5c}\",\"code\":\"10000\"}",
+		},
+		{
+			name: "nested key with same name",
+			body: `{"other":{"alipay_trade_query_response":{"code":"40004"}},"alipay_trade_query_response":{"code":"10000"},"sign":"abc"}`,
+			node: "alipay_trade_query_response",
+			want: `{"code":"10000"}`,
+		},
+		{
+			name: "scalar top level values",
+			body: `{"count":12,"ok":true,"alipay_trade_query_response":{"code":"10000"},"empty":null}`,
+			node: "alipay_trade_query_response",
+			want: `{"code":"10000"}`,
+		},
+		{
+			name: "duplicate node",
+			body: `{"alipay_trade_query_response":{"code":"10000"},"alipay_trade_query_response":{"code":"10000"}}`,
+			node: "alipay_trade_query_response",
+			err:  true,
+		},
+		{
+			name: "duplicate node with escaped key",
+			body: `{"alipay_trade_query_response":{"code":"10000"},"alipay_trade_query_respons\u0065":{"code":"10000"}}`,
+			node: "alipay_trade_query_response",
+			err:  true,
+		},
+		{
+			name: "unterminated node",
+			body: `{"alipay_trade_query_response":{"code":"10000"`,
+			node: "alipay_trade_query_response",
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := responseNode([]byte(tt.body), tt.node, tt.charset)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("responseNode() = %s, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("responseNode() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("responseNode() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyResponse(t *testing.T) {
+	pay, key := newTestAliPay(t)
+
+	node := `{"code":"10000","msg":"Success","subject":"a\"}b","trade_status":"TRADE_SUCCESS"}`
+	failureNode := `{"code":"40004","msg":"Business Failed","sub_code":"ACQ.TRADE_HAS_SUCCESS"}`
+	encryptedNode := `"4AOYvHA/jZ+Y6x=="`
+
+	tests := []struct {
+		name string
+		body string
+		err  func(error) bool
+	}{
+		{
+			name: "signed",
+			body: `{"alipay_trade_query_response":` + node + `,"sign":"` + signTest(t, key, node) + `"}`,
+		},
+		{
+			name: "signed encrypted string node",
+			body: `{"alipay_trade_query_response":` + encryptedNode + `,"sign":"` + signTest(t, key, encryptedNode) + `"}`,
+		},
+		{
+			name: "tampered body",
+			body: `{"alipay_trade_query_response":` + node[:len(node)-2] + `X"}` + `,"sign":"` + signTest(t, key, node) + `"}`,
+			err:  isResponseSignError,
+		},
+		{
+			name: "sign of other node",
+			body: `{"alipay_trade_query_response":` + failureNode + `,"sign":"` + signTest(t, key, node) + `"}`,
+			err:  isResponseSignError,
+		},
+		{
+			name: "unsigned business failure",
+			body: `{"alipay_trade_query_response":` + failureNode + `}`,
+			err:  isResponseSignError,
+		},
+		{
+			name: "unsigned success",
+			body: `{"alipay_trade_query_response":` + node + `}`,
+			err:  isResponseSignError,
+		},
+		{
+			name: "error response",
+			body: `{"error_response":{"code":"40002","msg":"Invalid Arguments","sub_code":"isv.invalid-signature"}}`,
+			err: func(err error) bool {
+				e, ok := AsError(err)
+				return ok && e.SubCode == "isv.invalid-signature"
+			},
+		},
+		{
+			name: "missing node",
+			body: `{"sign":"abc"}`,
+			err:  isResponseSignError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pay.verifyResponse(context.Background(), "alipay.trade.query", []byte(tt.body), CharsetUtf8)
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("verifyResponse() error = %v", err)
+				}
+				return
+			}
+			if !tt.err(err) {
+				t.Fatalf("verifyResponse() error = %v", err)
+			}
+		})
+	}
+}
+
+// roundTripFunc 模拟支付宝网关响应
+type roundTripFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// gatewayResponse 返回固定响应内容的模拟网关
+func gatewayResponse(body string) Option {
+	return WithTransport(roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json;charset=utf-8"}},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    request,
+		}, nil
+	}))
+}
+
+func TestQueryUsesVerifiedNode(t *testing.T) {
+	_, key := newTestAliPay(t)
+	signed := `{"code":"10000","msg":"Success","out_trade_no":"OTHER","trade_status":"WAIT_BUYER_PAY","total_amount":"1.00"}`
+	forged := `{"code":"10000","msg":"Success","out_trade_no":"MINE","trade_status":"TRADE_SUCCESS","total_amount":"999.00"}`
+	sign := signTest(t, key, signed)
+
+	tests := []struct {
+		name string
+		body string
+		err  bool
+	}{
+		{
+			name: "signed",
+			body: `{"alipay_trade_query_response":` + signed + `,"sign":"` + sign + `"}`,
+		},
+		{
+			name: "forged duplicate after signed node",
+			body: `{"alipay_trade_query_response":` + signed + `,"sign":"` + sign + `","alipay_trade_query_response":` + forged + `}`,
+			err:  true,
+		},
+		{
+			name: "forged duplicate with escaped key",
+			body: `{"alipay_trade_query_response":` + signed + `,"sign":"` + sign + `","alipay_trade_query_respons\u0065":` + forged + `}`,
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pay, _ := newTestAliPay(t, gatewayResponse(tt.body))
+			pay.alipayPublicKey = &key.PublicKey
+
+			result, err := pay.Query(context.Background(), "OTHER", "")
+			if tt.err {
+				if !isResponseSignError(err) {
+					t.Fatalf("Query() = %+v, error = %v, want sign error", result, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if result.OutTradeNo != "OTHER" || result.TradeStatus != "WAIT_BUYER_PAY" {
+				t.Fatalf("Query() = %+v, want signed node", result)
+			}
+		})
+	}
+}
+
+func TestVerifyResponseGbk(t *testing.T) {
+	pay, key := newTestAliPay(t, WithCharset(CharsetGbk))
+
+	// 乗 的GBK编码为 0x81 0x5C，尾字节与转义符相同
+	node := "{\"code\":\"10000\",\"msg\":\"Success\",\"subject\":\"\x81\x5c\"}"
+	body := "{\"alipay_trade_query_response\":" + node + ",\"sign\":\"" + signTest(t, key, node) + "\"}"
+
+	verified, err := pay.verifyResponse(context.Background(), "alipay.trade.query", []byte(body), CharsetGbk)
+	if err != nil {
+		t.Fatalf("verifyResponse() error = %v", err)
+	}
+	if string(verified) != node {
+		t.Fatalf("verifyResponse() = %q, want %q", verified, node)
+	}
+}
+
+func TestCheckCallbackSign(t *testing.T) {
+	pay, key := newTestAliPay(t)
+
+	params := map[string]string{
+		"app_id":       pay.config.appId,
+		"out_trade_no": "20240101000001",
+		"subject":      "测试商品 a=b&c",
+		"total_amount": "9.90",
+		"trade_status": "TRADE_SUCCESS",
+		"empty":        "",
+	}
+	content := pay.signString(params)
+	if want := "app_id=" + pay.config.appId + "&out_trade_no=20240101000001&subject=测试商品 a=b&c&total_amount=9.90&trade_status=TRADE_SUCCESS"; content != want {
+		t.Fatalf("signString() = %s, want %s", content, want)
+	}
+
+	values := func(sign string, overrides map[string]string) url.Values {
+		v := url.Values{}
+		for key, value := range params {
+			v.Set(key, value)
+		}
+		v.Set("sign_type", "RSA2")
+		if sign != "" {
+			v.Set("sign", sign)
+		}
+		for key, value := range overrides {
+			v.Set(key, value)
+		}
+		return v
+	}
+	sign := signTest(t, key, content)
+
+	tests := []struct {
+		name   string
+		values url.Values
+		ok     bool
+		err    bool
+	}{
+		{name: "valid", values: values(sign, nil), ok: true},
+		{name: "tampered amount", values: values(sign, map[string]string{"total_amount": "0.01"})},
+		{name: "added param", values: values(sign, map[string]string{"refund_fee": "9.90"})},
+		{name: "missing sign", values: values("", nil), err: true},
+		{name: "unsupported sign type", values: values(sign, map[string]string{"sign_type": "RSA"}), err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := pay.CheckCallbackSign(context.Background(), tt.values)
+			if tt.err && err == nil {
+				t.Fatal("CheckCallbackSign() want error")
+			}
+			if ok != tt.ok {
+				t.Fatalf("CheckCallbackSign() = %v, want %v, error = %v", ok, tt.ok, err)
+			}
+		})
+	}
+
+	t.Run("gbk unencodable param", func(t *testing.T) {
+		ok, err := pay.CheckCallbackSign(context.Background(), values(sign, map[string]string{
+			"charset": CharsetGbk,
+			"subject": "\U0001F600",
+		}))
+		if ok || err == nil || errors.Is(err, cryptoRsa.ErrVerification) {
+			t.Fatalf("CheckCallbackSign() = %v, error = %v, want encode error", ok, err)
+		}
+	})
+}
+
+// isResponseSignError 是否为验签失败错误
+func isResponseSignError(err error) bool {
+	return errors.Is(err, ErrResponseSign)
+}
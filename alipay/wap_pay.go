@@ -0,0 +1,64 @@
+package alipay
+
+// wapPayProductCode 手机网站支付产品码
+const wapPayProductCode = "QUICK_WAP_WAY"
+
+// TradeWapPayRequest 手机网站支付请求参数
+type TradeWapPayRequest struct {
+	OutTradeNo      string          `json:"out_trade_no" validate:"required,max=64"`
+	TotalAmount     string          `json:"total_amount" validate:"required,amount"`
+	Subject         string          `json:"subject" validate:"required,max=256"`
+	ProductCode     string          `json:"product_code,omitempty" validate:"max=64"`
+	QuitUrl         string          `json:"quit_url,omitempty" validate:"max=400"`
+	Body            string          `json:"body,omitempty" validate:"max=128"`
+	GoodsDetail     []GoodsDetail   `json:"goods_detail,omitempty"`
+	TimeExpire      string          `json:"time_expire,omitempty" validate:"max=32"`
+	TimeoutExpress  string          `json:"timeout_express,omitempty" validate:"max=6"`
+	ExtendParams    *ExtendParams   `json:"extend_params,omitempty"`
+	BusinessParams  *BusinessParams `json:"business_params,omitempty"`
+	PassbackParams  string          `json:"passback_params,omitempty" validate:"max=512"`
+	MerchantOrderNo string          `json:"merchant_order_no,omitempty" validate:"max=32"`
+	SettleInfo      *SettleInfo     `json:"settle_info,omitempty"`
+}
+
+// Validate 校验手机网站支付请求参数
+func (req *TradeWapPayRequest) Validate() error {
+	return validateFields(req)
+}
+
+// WapPay 手机网站支付，生成跳转至支付宝H5收银台的地址
+// 支付完成后跳转至 WithReturnUrl 设置的地址，支付结果异步通知至 WithNotifyUrl 设置的地址
+// @params bizContent interface{} 业务数据，*TradeWapPayRequest 或 map[string]interface{}
+// @params quitUrl string 用户付款中途退出返回商户网站的地址
+func (pay *AliPay) WapPay(bizContent interface{}, quitUrl string) (payUrl string, err error) {
+	biz, err := wapPayBizContent(bizContent, quitUrl)
+	if err != nil {
+		return "", err
+	}
+	return pay.pageUrl("alipay.trade.wap.pay", biz)
+}
+
+// WapPayForm 手机网站支付，生成自动提交至支付宝H5收银台的HTML表单
+// @params bizContent interface{} 业务数据，*TradeWapPayRequest 或 map[string]interface{}
+// @params quitUrl string 用户付款中途退出返回商户网站的地址
+func (pay *AliPay) WapPayForm(bizContent interface{}, quitUrl string) (form string, err error) {
+	biz, err := wapPayBizContent(bizContent, quitUrl)
+	if err != nil {
+		return "", err
+	}
+	return pay.pageForm("alipay.trade.wap.pay", biz)
+}
+
+// wapPayBizContent 补全手机网站支付产品码及退出地址
+func wapPayBizContent(bizContent interface{}, quitUrl string) (map[string]interface{}, error) {
+	biz, err := bizMap(bizContent)
+	if err != nil {
+		return nil, err
+	}
+
+	biz = withBizDefault(biz, "product_code", wapPayProductCode)
+	if quitUrl != "" {
+		biz = withBizDefault(biz, "quit_url", quitUrl)
+	}
+	return biz, nil
+}
@@ -0,0 +1,73 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+
+	"github.com/pkg/errors"
+)
+
+// CBCEncrypt AES-CBC加密，使用PKCS#5填充
+// @param plaintext []byte 原始内容
+// @param key []byte 密钥，长度为16、24或32字节
+// @param iv []byte 初始向量，长度为16字节
+func CBCEncrypt(plaintext, key, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("iv length error")
+	}
+
+	plaintext = pkcs5Padding(plaintext, block.BlockSize())
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+
+	return ciphertext, nil
+}
+
+// CBCDecrypt AES-CBC解密，去除PKCS#5填充
+// @param ciphertext []byte 密文
+// @param key []byte 密钥，长度为16、24或32字节
+// @param iv []byte 初始向量，长度为16字节
+func CBCDecrypt(ciphertext, key, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("iv length error")
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, errors.New("ciphertext length error")
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	return pkcs5UnPadding(plaintext, block.BlockSize())
+}
+
+// pkcs5Padding PKCS#5填充
+func pkcs5Padding(data []byte, blockSize int) []byte {
+	padding := blockSize - len(data)%blockSize
+	return append(data[:len(data):len(data)], bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
+
+// pkcs5UnPadding 去除PKCS#5填充
+func pkcs5UnPadding(data []byte, blockSize int) ([]byte, error) {
+	length := len(data)
+	padding := int(data[length-1])
+	if padding == 0 || padding > blockSize || padding > length {
+		return nil, errors.New("padding error")
+	}
+	for _, b := range data[length-padding:] {
+		if int(b) != padding {
+			return nil, errors.New("padding error")
+		}
+	}
+
+	return data[:length-padding], nil
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
+	"strings"
 
 	supportCrypto "github.com/dysodeng/payment/support/crypto"
 	"github.com/pkg/errors"
@@ -16,24 +17,28 @@ import (
 // @param content string 原始内容
 // @param privateKey string 加密私钥
 func Encrypt(content, privateKey string) (string, error) {
-	block, _ := pem.Decode([]byte(privateKey))
-	if block == nil {
-		return "", errors.New("private_key error")
-	}
-
-	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	rsaPrivate, err := ParsePrivateKey(privateKey)
 	if err != nil {
 		return "", err
 	}
 
-	rsaPrivate, _ := private.(*rsa.PrivateKey)
+	return SignWithKey(content, rsaPrivate)
+}
+
+// SignWithKey 使用已解析的私钥签名（SHA256WithRSA）
+// @param content string 原始内容
+// @param privateKey *rsa.PrivateKey 私钥
+func SignWithKey(content string, privateKey *rsa.PrivateKey) (string, error) {
+	if privateKey == nil {
+		return "", errors.New("private_key error")
+	}
 
 	hashed, err := supportCrypto.Sha256([]byte(content))
 	if err != nil {
 		return "", err
 	}
 
-	sign, err := rsa.SignPKCS1v15(rand.Reader, rsaPrivate, crypto.SHA256, hashed)
+	sign, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hashed)
 	if err != nil {
 		return "", err
 	}
@@ -46,17 +51,22 @@ func Encrypt(content, privateKey string) (string, error) {
 // @param sign string 签名串
 // @param publicKey string 公钥
 func Check(content, sign, publicKey string) (bool, error) {
-	block, _ := pem.Decode([]byte(publicKey))
-	if block == nil {
-		return false, errors.New("public_key error")
-	}
-
-	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	rsaPublic, err := ParsePublicKey(publicKey)
 	if err != nil {
 		return false, err
 	}
 
-	rsaPublic, _ := public.(*rsa.PublicKey)
+	return CheckWithKey(content, sign, rsaPublic)
+}
+
+// CheckWithKey 使用已解析的公钥验证签名（SHA256WithRSA）
+// @param content string 待验签内容
+// @param sign string 签名串
+// @param publicKey *rsa.PublicKey 公钥
+func CheckWithKey(content, sign string, publicKey *rsa.PublicKey) (bool, error) {
+	if publicKey == nil {
+		return false, errors.New("public_key error")
+	}
 
 	digest, err := supportCrypto.Sha256([]byte(content))
 	if err != nil {
@@ -68,10 +78,89 @@ func Check(content, sign, publicKey string) (bool, error) {
 		return false, err
 	}
 
-	err = rsa.VerifyPKCS1v15(rsaPublic, crypto.SHA256, digest, data)
+	err = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest, data)
 	if err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
+
+// ParsePrivateKey 解析RSA私钥
+// 支持PEM格式（PKCS#1 或 PKCS#8）及不含PEM头尾的base64编码内容
+// @param privateKey string 私钥内容
+func ParsePrivateKey(privateKey string) (*rsa.PrivateKey, error) {
+	der, err := keyBytes(privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "private_key error")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "private_key error: 无法按 PKCS#1 或 PKCS#8 格式解析私钥")
+	}
+
+	rsaPrivate, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private_key error: 私钥不是RSA私钥")
+	}
+
+	return rsaPrivate, nil
+}
+
+// ParsePublicKey 解析RSA公钥
+// 支持PEM格式（PKIX 或 PKCS#1）、X.509证书及不含PEM头尾的base64编码内容
+// @param publicKey string 公钥内容
+func ParsePublicKey(publicKey string) (*rsa.PublicKey, error) {
+	der, err := keyBytes(publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "public_key error")
+	}
+
+	var key interface{}
+	if key, err = x509.ParsePKIXPublicKey(der); err != nil {
+		if key, err = x509.ParsePKCS1PublicKey(der); err != nil {
+			cert, certErr := x509.ParseCertificate(der)
+			if certErr != nil {
+				return nil, errors.Wrap(err, "public_key error: 无法按 PKIX 或 PKCS#1 格式解析公钥")
+			}
+			key = cert.PublicKey
+		}
+	}
+
+	rsaPublic, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public_key error: 公钥不是RSA公钥")
+	}
+
+	return rsaPublic, nil
+}
+
+// keyBytes 获取密钥的DER编码内容，PEM格式时解码首个块，否则按base64解码
+func keyBytes(key string) ([]byte, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, errors.New("密钥内容为空")
+	}
+
+	if strings.HasPrefix(key, "-----BEGIN") {
+		block, _ := pem.Decode([]byte(key))
+		if block == nil {
+			return nil, errors.New("PEM格式错误")
+		}
+		return block.Bytes, nil
+	}
+
+	// 去除换行等空白字符后按base64解码
+	key = strings.Join(strings.Fields(key), "")
+	der, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "密钥须为PEM格式或base64编码")
+	}
+
+	return der, nil
+}
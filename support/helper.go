@@ -2,6 +2,7 @@ package support
 
 import (
 	"bytes"
+	"io"
 	"io/ioutil"
 	"math/rand"
 
@@ -26,6 +27,21 @@ func GbkToUtf8(s []byte) ([]byte, error) {
 	return d, nil
 }
 
+// Utf8ToGbk UTF-8 转 GBK
+func Utf8ToGbk(s []byte) ([]byte, error) {
+	reader := transform.NewReader(bytes.NewReader(s), simplifiedchinese.GBK.NewEncoder())
+	d, e := ioutil.ReadAll(reader)
+	if e != nil {
+		return nil, e
+	}
+	return d, nil
+}
+
+// GbkToUtf8Reader 将GBK编码的数据流转换为UTF-8数据流
+func GbkToUtf8Reader(r io.Reader) io.Reader {
+	return transform.NewReader(r, simplifiedchinese.GBK.NewDecoder())
+}
+
 // RandStringBytesMask 生成随机字符串
 // @param int length 生成字符串长度
 // @return string